@@ -0,0 +1,36 @@
+// Command eventschemas writes a JSON Schema document for every registered
+// event type, one <Type>.v<Version>.json file per type, so consumers can
+// validate or generate code for the credit_events stream.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"api/internal/events"
+)
+
+func main() {
+	dir := flag.String("o", "schemas/events", "output directory")
+	flag.Parse()
+
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		log.Fatalf("create %s: %v", *dir, err)
+	}
+
+	for _, def := range events.Definitions() {
+		data, err := json.MarshalIndent(def.JSONSchema(), "", "  ")
+		if err != nil {
+			log.Fatalf("encode %s: %v", def.Type, err)
+		}
+
+		name := filepath.Join(*dir, fmt.Sprintf("%s.v%d.json", def.Type, def.Version))
+		if err := os.WriteFile(name, append(data, '\n'), 0o644); err != nil {
+			log.Fatalf("write %s: %v", name, err)
+		}
+	}
+}
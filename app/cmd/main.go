@@ -7,18 +7,30 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
 
 	"api/internal/config"
+	"api/internal/eligibility"
 	"api/internal/events"
 	"api/internal/handlers"
+	"api/internal/health"
+	"api/internal/idempotency"
+	"api/internal/metrics"
 	_ "api/internal/handlers/banks"
 	_ "api/internal/handlers/clients"
 	_ "api/internal/handlers/credits"
+	_ "api/internal/handlers/eligibility"
+	_ "api/internal/handlers/webhooks"
 	mw "api/internal/middleware"
+	"api/internal/outbox"
+	"api/internal/repository"
+	"api/internal/tracing"
 	"api/pkg/database"
+	baseRepo "api/pkg/repository"
 )
 
 func main() {
@@ -49,18 +61,48 @@ func main() {
 		syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 	defer cancel()
 
-	if err := database.ConnectRedis(ctx, cfg.GetRedisAddr()); err != nil {
-		log.Error("failed to connect to redis", "err", err)
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Options{
+		Exporter:    cfg.TracesExporter,
+		ServiceName: cfg.ServiceName,
+	})
+	if err != nil {
+		log.Error("failed to set up tracing", "err", err)
 		os.Exit(1)
 	}
 
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			log.Error("flushing traces failed", "err", err)
+		}
+	}()
+
+	// without Redis the API still serves from Postgres, uncached
+	err = database.ConnectRedis(ctx, cfg.GetRedisAddr(), database.BreakerOptions{
+		Threshold: cfg.RedisBreakerThreshold,
+		Cooldown:  cfg.RedisBreakerCooldown,
+	})
+	if err != nil {
+		log.Warn("redis unavailable, starting degraded", "err", err)
+	}
+
+	go database.MonitorRedis(ctx, cfg.RedisBreakerCooldown)
+
 	defer database.CloseRedis()
 
-	go database.StartRedisHealthCheck(ctx, cfg.RedisHealthCheckInterval)
+	registry := prometheus.NewRegistry()
+	m := metrics.New(registry)
+	m.RegisterRuntime()
+	baseRepo.SetCacheStats(m)
 
-	publisher := events.NewRedisPublisher(database.Redis())
+	publisher := m.Publisher(events.NewRedisPublisher(database.Redis()))
 
-	db, err := database.Connect(ctx, cfg.GetDBDSN(), cfg.DBMaxConns, cfg.DBMinConns)
+	db, err := database.ConnectCluster(ctx, cfg.GetDBDSN(), cfg.GetReplicaDSNs(),
+		cfg.DBMaxConns, cfg.DBMinConns, database.ReplicaLimits{
+			MaxLag:      cfg.DBReplicaMaxLag,
+			MaxLagBytes: cfg.DBReplicaMaxLagBytes,
+		})
 
 	if err != nil {
 		log.Error("failed to connect to database", "err", err)
@@ -69,6 +111,42 @@ func main() {
 
 	defer db.Close()
 
+	go db.MonitorReplicas(ctx, cfg.DBReplicaCheckTime)
+
+	m.RegisterPools(db.Pools(), db.Replicas)
+
+	if cfg.CursorSecret == "" {
+		log.Warn("CURSOR_SECRET is not set, pagination cursors break on restart and across instances")
+	}
+	baseRepo.SetCursorSecret(cfg.CursorSecret)
+	go baseRepo.SubscribeCacheInvalidations(ctx, database.Redis())
+	go baseRepo.MaintainListIndexes(ctx, db.Primary(), cfg.ListIndexCheckInterval)
+
+	engine := eligibility.NewEngine(repository.NewRuleSetRepository(db))
+	engine.Observe(m.ObserveEligibility)
+	if err := engine.Reload(ctx); err != nil {
+		log.Error("failed to load eligibility rules, using defaults", "err", err)
+	}
+
+	go engine.Watch(ctx, cfg.RulesReloadInterval)
+
+	relay := outbox.NewRelay(db.Primary(), publisher, cfg.OutboxBatchSize)
+	go relay.Run(ctx, cfg.OutboxPollInterval)
+
+	probes := health.NewRegistry(cfg.HealthCacheTTL)
+	probes.Register(health.Postgres(db))
+	probes.Register(health.Redis(database.Redis()))
+	probes.Register(health.Stream(database.Redis(), events.CreditEventsStream))
+	for name, pool := range db.Pools() {
+		probes.Register(health.PoolSaturation(name, pool, cfg.HealthPoolSaturation))
+	}
+
+	guard := idempotency.NewGuard(idempotency.NewRedisStore(database.Redis()), idempotency.Options{
+		TTL:     cfg.IdempotencyTTL,
+		LockTTL: cfg.IdempotencyLockTTL,
+		Wait:    cfg.IdempotencyWait,
+	})
+
 	r := chi.NewRouter()
 
 	r.Use(middleware.RequestID)
@@ -77,6 +155,9 @@ func main() {
 	r.Use(middleware.Timeout(cfg.ReadHeaderTimeout))
 	r.Use(mw.DBMiddleware(db))
 	r.Use(mw.PublisherMiddleware(publisher))
+	r.Use(mw.EligibilityMiddleware(engine))
+	r.Use(mw.IdempotencyMiddleware(guard))
+	r.Use(mw.MetricsMiddleware(m))
 	r.Use(mw.LoggerMiddleware(log))
 
 	r.Group(func(r chi.Router) {
@@ -94,6 +175,10 @@ func main() {
 
     handlers.RegisterAll(r)
 
+	r.Get("/livez", probes.LiveHandler())
+	r.Get("/readyz", probes.ReadyHandler())
+	r.Handle("/metrics", metrics.Handler(registry))
+
 	srv := &http.Server{
 		Addr:              ":" + cfg.Port,
 		Handler:           r,
@@ -112,7 +197,11 @@ func main() {
 	}()
 
 	<-ctx.Done()
-	log.Info("shutdown signal received")
+	log.Info("shutdown signal received, draining", "for", cfg.ShutdownDrain)
+
+	// fail readiness first and keep serving while load balancers notice
+	probes.Shutdown()
+	time.Sleep(cfg.ShutdownDrain)
 
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.ShutdownGrace)
 	defer shutdownCancel()
@@ -0,0 +1,47 @@
+// Command openapi writes the OpenAPI document of the API to stdout or a file,
+// without connecting to Postgres or Redis, so SDKs can be generated in CI.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"os"
+
+	"api/internal/handlers"
+	_ "api/internal/handlers/banks"
+	_ "api/internal/handlers/clients"
+	_ "api/internal/handlers/credits"
+	_ "api/internal/handlers/eligibility"
+	_ "api/internal/handlers/webhooks"
+	"api/internal/openapi"
+)
+
+func main() {
+	out := flag.String("o", "", "output file (default stdout)")
+	server := flag.String("server", "", "server URL to include in the document")
+	flag.Parse()
+
+	var servers []openapi.Server
+	if *server != "" {
+		servers = append(servers, openapi.Server{URL: *server})
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			log.Fatalf("create %s: %v", *out, err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(handlers.OpenAPI(servers)); err != nil {
+		log.Fatalf("encode spec: %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"api/internal/config"
+	"api/internal/events"
+	"api/internal/tracing"
+	"api/internal/webhooks"
+	"api/pkg/database"
+)
+
+func main() {
+	cfg := config.MustLoad()
+
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level:     cfg.LogLevel,
+		AddSource: true,
+	}))
+
+	slog.SetDefault(log)
+
+	ctx, cancel := signal.NotifyContext(context.Background(),
+		syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	defer cancel()
+
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Options{
+		Exporter:    cfg.TracesExporter,
+		ServiceName: cfg.ServiceName,
+	})
+	if err != nil {
+		log.Error("failed to set up tracing", "err", err)
+		os.Exit(1)
+	}
+
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			log.Error("flushing traces failed", "err", err)
+		}
+	}()
+
+	if err := database.ConnectRedis(ctx, cfg.GetRedisAddr(), database.BreakerOptions{
+		Threshold: cfg.RedisBreakerThreshold,
+		Cooldown:  cfg.RedisBreakerCooldown,
+	}); err != nil {
+		log.Error("failed to connect to redis", "err", err)
+		os.Exit(1)
+	}
+
+	defer database.CloseRedis()
+
+	db, err := database.Connect(ctx, cfg.GetDBDSN(), cfg.DBMaxConns, cfg.DBMinConns)
+	if err != nil {
+		log.Error("failed to connect to database", "err", err)
+		os.Exit(1)
+	}
+
+	defer db.Close()
+
+	dispatcher := webhooks.NewDispatcher(db, webhooks.Options{
+		MaxAttempts: cfg.WebhookMaxAttempts,
+		Timeout:     cfg.WebhookTimeout,
+	})
+
+	consumer := events.NewConsumer(database.Redis(), events.ConsumerOptions{
+		Stream:      events.CreditEventsStream,
+		Group:       cfg.WorkerGroup,
+		Name:        cfg.WorkerName,
+		MaxAttempts: cfg.WorkerMaxAttempts,
+	})
+
+	// Partner banks are notified through their webhooks
+	events.Handle(consumer, events.TypeCreditCreated, func(ctx context.Context, event events.Event, payload events.CreditCreatedEvent) error {
+		return dispatcher.Enqueue(ctx, event, payload.BankID)
+	})
+
+	events.Handle(consumer, events.TypeCreditApproved, func(ctx context.Context, event events.Event, payload events.CreditApprovedEvent) error {
+		return dispatcher.Enqueue(ctx, event, payload.BankID)
+	})
+
+	events.Handle(consumer, events.TypeCreditRejected, func(ctx context.Context, event events.Event, payload events.CreditRejectedEvent) error {
+		return dispatcher.Enqueue(ctx, event, payload.BankID)
+	})
+
+	go dispatcher.Run(ctx, cfg.WebhookPollInterval)
+
+	log.Info("worker started", "group", cfg.WorkerGroup, "consumer", cfg.WorkerName)
+
+	if err := consumer.Run(ctx); err != nil {
+		log.Error("worker failed", "err", err)
+		os.Exit(1)
+	}
+
+	log.Info("worker stopped")
+}
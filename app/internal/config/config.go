@@ -5,6 +5,7 @@ import (
 	"log/slog"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -18,6 +19,11 @@ type Config struct {
 	IdleTimeout       time.Duration
 	ReadHeaderTimeout time.Duration
 	ShutdownGrace     time.Duration
+	// ShutdownDrain is how long /readyz fails before the server stops
+	ShutdownDrain time.Duration
+
+	HealthCacheTTL       time.Duration
+	HealthPoolSaturation float64
 
 	DBHost     string
 	DBPort     string
@@ -28,11 +34,45 @@ type Config struct {
 	DBMaxConns int32
 	DBMinConns int32
 
-	RedisHost                string
-	RedisPort                string
-	RedisPassword            string
-	RedisDB                  int
-	RedisHealthCheckInterval time.Duration
+	// DBReplicas are "host:port/dbname" addresses of read replicas, reached
+	// with the primary's credentials
+	DBReplicas           []string
+	DBReplicaMaxLag      time.Duration
+	DBReplicaMaxLagBytes int64
+	DBReplicaCheckTime   time.Duration
+
+	RedisHost     string
+	RedisPort     string
+	RedisPassword string
+	RedisDB       int
+
+	RedisBreakerThreshold int
+	RedisBreakerCooldown  time.Duration
+
+	CursorSecret string
+
+	IdempotencyTTL     time.Duration
+	IdempotencyLockTTL time.Duration
+	IdempotencyWait    time.Duration
+
+	RulesReloadInterval time.Duration
+
+	ListIndexCheckInterval time.Duration
+
+	OutboxPollInterval time.Duration
+	OutboxBatchSize    int
+
+	WorkerGroup       string
+	WorkerName        string
+	WorkerMaxAttempts int
+
+	WebhookTimeout      time.Duration
+	WebhookMaxAttempts  int
+	WebhookPollInterval time.Duration
+
+	// TracesExporter is none, otlp or stdout
+	TracesExporter string
+	ServiceName    string
 }
 
 func (c Config) LogLevelString() string {
@@ -61,6 +101,20 @@ func (c *Config) GetDBDSN() string {
     )
 }
 
+func (c *Config) GetReplicaDSNs() []string {
+	dsns := make([]string, 0, len(c.DBReplicas))
+	for _, addr := range c.DBReplicas {
+		dsns = append(dsns, fmt.Sprintf("postgres://%s:%s@%s?sslmode=%s",
+			c.DBUser,
+			c.DBPassword,
+			addr,
+			c.DBSSLMode,
+		))
+	}
+
+	return dsns
+}
+
 func (c *Config) GetRedisAddr() string {
 	return c.RedisHost + ":" + c.RedisPort
 }
@@ -89,6 +143,9 @@ func MustLoad() Config {
 	cfg.IdleTimeout = durationEnvOr("IDLE_TIMEOUT_SEC", 60*time.Second)
 	cfg.ReadHeaderTimeout = durationEnvOr("READ_HEADER_TIMEOUT_SEC", 5*time.Second)
 	cfg.ShutdownGrace = durationEnvOr("SHUTDOWN_GRACE_SEC", 15*time.Second)
+	cfg.ShutdownDrain = durationEnvOr("SHUTDOWN_DRAIN_SEC", 5*time.Second)
+	cfg.HealthCacheTTL = durationEnvOr("HEALTH_CACHE_SEC", time.Second)
+	cfg.HealthPoolSaturation = float64(intEnvOr("HEALTH_POOL_SATURATION_PCT", 90)) / 100
 
 	cfg.DBHost = envOr("DB_HOST", "odyssey")
 	cfg.DBPort = envOr("DB_PORT", "6432")
@@ -98,12 +155,42 @@ func MustLoad() Config {
 	cfg.DBSSLMode = envOr("DB_SSLMODE", "disable")
 	cfg.DBMaxConns = int32(intEnvOr("DB_MAX_CONNS", 25))
 	cfg.DBMinConns = int32(intEnvOr("DB_MIN_CONNS", 5))
+	cfg.DBReplicas = listEnvOr("DB_REPLICAS", nil)
+	cfg.DBReplicaMaxLag = durationEnvOr("DB_REPLICA_MAX_LAG_SEC", 5*time.Second)
+	cfg.DBReplicaMaxLagBytes = int64(intEnvOr("DB_REPLICA_MAX_LAG_BYTES", 16<<20))
+	cfg.DBReplicaCheckTime = durationEnvOr("DB_REPLICA_CHECK_SEC", 5*time.Second)
 
 	cfg.RedisHost = envOr("REDIS_HOST", "redis")
 	cfg.RedisPort = envOr("REDIS_PORT", "6379")
 	cfg.RedisPassword = envOr("REDIS_PASSWORD", "")
 	cfg.RedisDB = intEnvOr("REDIS_DB", 0)
-	cfg.RedisHealthCheckInterval = durationEnvOr("REDIS_HEALTH_CHECK_SEC", 30*time.Second)
+	cfg.RedisBreakerThreshold = intEnvOr("REDIS_BREAKER_THRESHOLD", 5)
+	cfg.RedisBreakerCooldown = durationEnvOr("REDIS_BREAKER_COOLDOWN_SEC", 5*time.Second)
+
+	cfg.CursorSecret = envOr("CURSOR_SECRET", "")
+
+	cfg.IdempotencyTTL = durationEnvOr("IDEMPOTENCY_TTL_SEC", 24*time.Hour)
+	cfg.IdempotencyLockTTL = durationEnvOr("IDEMPOTENCY_LOCK_SEC", 30*time.Second)
+	cfg.IdempotencyWait = durationEnvOr("IDEMPOTENCY_WAIT_SEC", 5*time.Second)
+
+	cfg.RulesReloadInterval = durationEnvOr("RULES_RELOAD_SEC", 30*time.Second)
+
+	cfg.ListIndexCheckInterval = durationEnvOr("LIST_INDEX_CHECK_SEC", time.Minute)
+
+	cfg.OutboxPollInterval = durationEnvOr("OUTBOX_POLL_SEC", time.Second)
+	cfg.OutboxBatchSize = intEnvOr("OUTBOX_BATCH_SIZE", 100)
+
+	hostname, _ := os.Hostname()
+	cfg.WorkerGroup = envOr("WORKER_GROUP", "worker")
+	cfg.WorkerName = envOr("WORKER_NAME", hostname)
+	cfg.WorkerMaxAttempts = intEnvOr("WORKER_MAX_ATTEMPTS", 5)
+
+	cfg.WebhookTimeout = durationEnvOr("WEBHOOK_TIMEOUT_SEC", 10*time.Second)
+	cfg.WebhookMaxAttempts = intEnvOr("WEBHOOK_MAX_ATTEMPTS", 8)
+	cfg.WebhookPollInterval = durationEnvOr("WEBHOOK_POLL_SEC", time.Second)
+
+	cfg.TracesExporter = envOr("OTEL_TRACES_EXPORTER", "none")
+	cfg.ServiceName = envOr("OTEL_SERVICE_NAME", "credits-api")
 
 	return cfg
 }
@@ -115,6 +202,25 @@ func envOr(key, fallback string) string {
 	return fallback
 }
 
+// listEnvOr splits a comma separated variable; "none" yields an empty list
+func listEnvOr(key string, fallback []string) []string {
+	s := envOr(key, "")
+	if s == "" {
+		return fallback
+	}
+	if s == "none" {
+		return nil
+	}
+
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
 func intEnvOr(key string, fallback int) int {
 	s := envOr(key, "")
 	if s == "" {
@@ -1,6 +1,9 @@
 package banks
 
-import "api/internal/contracts"
+import (
+	"api/internal/contracts"
+	"api/internal/domain"
+)
 
 var Create = contracts.Contract{
 	Method: "POST",
@@ -16,4 +19,5 @@ var Create = contracts.Contract{
 			Options: []string{"PRIVATE", "GOVERNMENT"},
 		},
 	},
+	Response: domain.Bank{},
 }
\ No newline at end of file
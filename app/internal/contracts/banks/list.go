@@ -1,11 +1,15 @@
 package banks
 
-import "api/internal/contracts"
+import (
+	"api/internal/contracts"
+	"api/internal/domain"
+	"api/pkg/repository"
+)
 
 var List = contracts.Contract{
     Method: "GET",
 	URI:    "/banks",
-    Optional: map[string]contracts.FieldSpec{
+    Query: contracts.WithPagination(map[string]contracts.FieldSpec{
         "name": {
             Type: "string",
             Min:  2,
@@ -15,5 +19,13 @@ var List = contracts.Contract{
             Type:    "enum",
             Options: []string{"PRIVATE", "GOVERNMENT"},
         },
-    },
+        "sort": {
+            Type:    "sort",
+            Options: []string{"id", "name", "created_at"},
+        },
+    }),
+	Response: contracts.OneOf{
+		repository.PaginatedResult[domain.Bank]{},
+		repository.CursorResult[domain.Bank]{},
+	},
 }
\ No newline at end of file
@@ -1,6 +1,9 @@
 package banks
 
-import "api/internal/contracts"
+import (
+	"api/internal/contracts"
+	"api/internal/domain"
+)
 
 var Update = contracts.Contract{
     Method: "PUT",
@@ -22,4 +25,9 @@ var Update = contracts.Contract{
             Options: []string{"PRIVATE", "GOVERNMENT"},
         },
     },
+	Rules: []contracts.Rule{
+		{Type: contracts.RuleAtLeastOne, Fields: []string{"name", "type"}},
+	},
+	Conditional: true,
+	Response:    domain.Bank{},
 }
\ No newline at end of file
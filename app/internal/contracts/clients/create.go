@@ -1,6 +1,9 @@
 package clients
 
-import "api/internal/contracts"
+import (
+	"api/internal/contracts"
+	"api/internal/domain"
+)
 
 var Create = contracts.Contract{
 	Method: "POST",
@@ -23,4 +26,5 @@ var Create = contracts.Contract{
 			Max:  100,
 		},
 	},
+	Response: domain.Client{},
 }
\ No newline at end of file
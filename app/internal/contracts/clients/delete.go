@@ -11,4 +11,6 @@ var Delete = contracts.Contract{
 			Min:  1,
 		},
 	},
+	Conditional: true,
+	Response:    contracts.DeleteResponse{},
 }
\ No newline at end of file
@@ -1,19 +1,32 @@
 package clients
 
-import "api/internal/contracts"
+import (
+	"api/internal/contracts"
+	"api/internal/domain"
+	"api/pkg/repository"
+)
 
 var List = contracts.Contract{
     Method: "GET",
 	URI:    "/clients",
-    Optional: map[string]contracts.FieldSpec{
-        "name": {
+    Query: contracts.WithPagination(map[string]contracts.FieldSpec{
+        "country": {
             Type: "string",
             Min:  2,
             Max:  100,
         },
-        "type": {
-            Type:    "enum",
-            Options: []string{"PRIVATE", "GOVERNMENT"},
+        "name_prefix": {
+            Type: "string",
+            Min:  1,
+            Max:  255,
+        },
+        "sort": {
+            Type:    "sort",
+            Options: []string{"id", "full_name", "country", "created_at"},
         },
-    },
+    }),
+	Response: contracts.OneOf{
+		repository.PaginatedResult[domain.Client]{},
+		repository.CursorResult[domain.Client]{},
+	},
 }
\ No newline at end of file
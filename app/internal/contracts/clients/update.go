@@ -1,9 +1,12 @@
 package clients
 
-import "api/internal/contracts"
+import (
+	"api/internal/contracts"
+	"api/internal/domain"
+)
 
 var Update = contracts.Contract{
-    Method: "GET",
+    Method: "PUT",
 	URI:    "/clients/{id}",
 	Required: map[string]contracts.FieldSpec{
         "id": {
@@ -29,4 +32,9 @@ var Update = contracts.Contract{
 			Max:  100,
 		},
 	},
+	Rules: []contracts.Rule{
+		{Type: contracts.RuleAtLeastOne, Fields: []string{"full_name", "email", "birth_date", "country"}},
+	},
+	Conditional: true,
+	Response:    domain.Client{},
 }
\ No newline at end of file
@@ -1,6 +1,9 @@
 package credits
 
-import "api/internal/contracts"
+import (
+	"api/internal/contracts"
+	"api/internal/domain"
+)
 
 var Create = contracts.Contract{
     Method: "POST",
@@ -30,4 +33,15 @@ var Create = contracts.Contract{
 			Options: []string{"AUTO", "MORTGAGE", "COMMERCIAL"},
 		},
 	},
+	Rules: []contracts.Rule{
+		{Type: contracts.RuleCompare, Field: "max_payment", Op: "gte", Other: "min_payment"},
+		{
+			Type:  contracts.RuleCompare,
+			Field: "term_months",
+			Op:    "gte",
+			Value: domain.MortgageMinTermMonths,
+			When:  &contracts.Condition{Field: "credit_type", Value: "MORTGAGE"},
+		},
+	},
+	Response: domain.Credit{},
 }
\ No newline at end of file
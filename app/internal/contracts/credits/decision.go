@@ -0,0 +1,18 @@
+package credits
+
+import (
+	"api/internal/contracts"
+	"api/internal/domain"
+)
+
+var Decision = contracts.Contract{
+	Method: "GET",
+	URI:    "/credits/{id}/decision",
+	Required: map[string]contracts.FieldSpec{
+		"id": {
+			Type: "int",
+			Min:  1,
+		},
+	},
+	Response: domain.Decision{},
+}
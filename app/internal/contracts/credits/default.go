@@ -0,0 +1,21 @@
+package credits
+
+import (
+	"net/http"
+
+	"api/internal/contracts"
+	"api/internal/domain"
+)
+
+var MarkDefaulted = contracts.Contract{
+	Method: "POST",
+	URI:    "/credits/{id}/default",
+	Required: map[string]contracts.FieldSpec{
+		"id": {
+			Type: "int",
+			Min:  1,
+		},
+	},
+	Status:   http.StatusOK,
+	Response: domain.Credit{},
+}
\ No newline at end of file
@@ -1,6 +1,9 @@
 package credits
 
-import "api/internal/contracts"
+import (
+	"api/internal/contracts"
+	"api/internal/domain"
+)
 
 var Get = contracts.Contract{
 	Method: "GET",
@@ -11,4 +14,6 @@ var Get = contracts.Contract{
 			Min:  1,
 		},
 	},
+	Conditional: true,
+	Response:    domain.Credit{},
 }
\ No newline at end of file
@@ -1,17 +1,66 @@
 package credits
 
-import "api/internal/contracts"
+import (
+	"api/internal/contracts"
+	"api/internal/domain"
+	"api/pkg/repository"
+)
 
 var List = contracts.Contract{
-    Optional: map[string]contracts.FieldSpec{
-        "name": {
-            Type: "string",
-            Min:  2,
-            Max:  100,
-        },
-        "type": {
-            Type:    "enum",
-            Options: []string{"PRIVATE", "GOVERNMENT"},
-        },
-    },
+	Method: "GET",
+	URI:    "/credits",
+	Query: contracts.WithPagination(map[string]contracts.FieldSpec{
+		"status": {
+			Type:    "enum",
+			Options: domain.CreditStatuses,
+		},
+		"credit_type": {
+			Type:    "enum",
+			Options: []string{"AUTO", "MORTGAGE", "COMMERCIAL"},
+		},
+		"bank_id": {
+			Type: "int",
+			Min:  1,
+		},
+		"client_id": {
+			Type: "int",
+			Min:  1,
+		},
+		"term_months_from": {
+			Type: "int",
+			Min:  1,
+			Max:  360,
+		},
+		"term_months_to": {
+			Type: "int",
+			Min:  1,
+			Max:  360,
+		},
+		"min_payment_from": {
+			Type: "number",
+		},
+		"min_payment_to": {
+			Type: "number",
+		},
+		"max_payment_from": {
+			Type: "number",
+		},
+		"max_payment_to": {
+			Type: "number",
+		},
+		"created_from": {
+			Type: "date",
+		},
+		"created_to": {
+			Type: "date",
+		},
+		"sort": {
+			Type:    "sort",
+			Options: []string{"id", "created_at", "term_months", "min_payment", "max_payment", "status"},
+		},
+	}),
+	Response: contracts.OneOf{
+		repository.PaginatedResult[domain.Credit]{},
+		repository.CursorResult[domain.Credit]{},
+	},
 }
\ No newline at end of file
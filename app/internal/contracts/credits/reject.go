@@ -0,0 +1,26 @@
+package credits
+
+import (
+	"net/http"
+
+	"api/internal/contracts"
+	"api/internal/domain"
+)
+
+var Reject = contracts.Contract{
+	Method: "POST",
+	URI:    "/credits/{id}/reject",
+	Required: map[string]contracts.FieldSpec{
+		"id": {
+			Type: "int",
+			Min:  1,
+		},
+		"reason": {
+			Type: "string",
+			Min:  3,
+			Max:  500,
+		},
+	},
+	Status:   http.StatusOK,
+	Response: domain.Credit{},
+}
\ No newline at end of file
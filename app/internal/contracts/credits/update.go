@@ -1,9 +1,12 @@
 package credits
 
-import "api/internal/contracts"
+import (
+	"api/internal/contracts"
+	"api/internal/domain"
+)
 
 var Update = contracts.Contract{
-    Method: "GET",
+    Method: "PUT",
 	URI:    "/credits/{id}",
 	Required: map[string]contracts.FieldSpec{
         "id": {
@@ -29,9 +32,12 @@ var Update = contracts.Contract{
 			Type:    "enum",
 			Options: []string{"AUTO", "MORTGAGE", "COMMERCIAL"},
 		},
-		"status": {
-			Type:    "enum",
-			Options: []string{"PENDING", "APPROVED", "REJECTED"},
-		},
 	},
+	// the rules of Create only apply to fields sent together, the service
+	// checks them again on the updated credit
+	Rules: append([]contracts.Rule{
+		{Type: contracts.RuleAtLeastOne, Fields: []string{"min_payment", "max_payment", "term_months", "credit_type"}},
+	}, Create.Rules...),
+	Conditional: true,
+	Response:    domain.Credit{},
 }
\ No newline at end of file
@@ -0,0 +1,19 @@
+package eligibility
+
+import (
+	"net/http"
+
+	"api/internal/contracts"
+	"api/internal/contracts/credits"
+	"api/internal/domain"
+)
+
+// Check scores a credit application without creating it
+var Check = contracts.Contract{
+	Method:   "POST",
+	URI:      "/eligibility/check",
+	Required: credits.Create.Required,
+	Rules:    credits.Create.Rules,
+	Status:   http.StatusOK,
+	Response: domain.Decision{},
+}
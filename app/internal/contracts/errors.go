@@ -0,0 +1,150 @@
+package contracts
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// codes are the stable machine codes of the sentinel errors, clients match
+// on these rather than on messages
+var codes = map[error]string{
+	ErrRequired:        "required",
+	ErrInvalidType:     "invalid_type",
+	ErrTooShort:        "too_short",
+	ErrTooLong:         "too_long",
+	ErrInvalidEnum:     "invalid_enum",
+	ErrUnexpectedField: "unexpected_field",
+	ErrUnsupportedType: "unsupported_type",
+	ErrInvalidEmail:    "invalid_email",
+	ErrInvalidDate:     "invalid_date",
+	ErrTooSmall:        "too_small",
+	ErrTooBig:          "too_big",
+	ErrInvalidUUID:     "invalid_uuid",
+	ErrInvalidSort:     "invalid_sort",
+	ErrInvalidURL:      "invalid_url",
+
+	ErrComparison:        "comparison_failed",
+	ErrMutuallyExclusive: "mutually_exclusive",
+	ErrAtLeastOne:        "at_least_one_required",
+}
+
+// FieldError is a failed check of one field. It unwraps to the sentinel
+// error, so errors.Is keeps working. Value is the rejected value, null for
+// a missing field.
+type FieldError struct {
+	Field       string         `json:"field"`
+	Code        string         `json:"code"`
+	Message     string         `json:"message"`
+	Constraints map[string]any `json:"constraints,omitempty"`
+	Value       any            `json:"value"`
+
+	err    error
+	detail string
+}
+
+func newFieldError(field string, err error, value any, constraints map[string]any, format string, args ...any) *FieldError {
+	fe := &FieldError{
+		Field:       field,
+		Code:        codes[err],
+		Message:     err.Error(),
+		Constraints: constraints,
+		Value:       value,
+		err:         err,
+	}
+
+	if format != "" {
+		fe.detail = fmt.Sprintf(format, args...)
+		fe.Message += ": " + fe.detail
+	}
+
+	return fe
+}
+
+// Error reads "required field missing: name" for errors about the field
+// itself and "name: value too short: ..." for errors about its value
+func (e *FieldError) Error() string {
+	if e.detail == "" {
+		return e.Message + ": " + e.Field
+	}
+
+	return e.Field + ": " + e.Message
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.err
+}
+
+// ValidationError holds every failing field of a request
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+// Add records err for field. Errors that are not a FieldError, e.g. from
+// Coerce, become one with the rejected value; a ValidationError is merged.
+func (e *ValidationError) Add(field string, value any, err error) {
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		e.Errors = append(e.Errors, verr.Errors...)
+		return
+	}
+
+	var fe *FieldError
+	if !errors.As(err, &fe) {
+		e.Errors = append(e.Errors, newFieldError(field, err, value, nil, ""))
+		return
+	}
+
+	if fe.Field != field {
+		copied := *fe
+		copied.Field = field
+		fe = &copied
+	}
+
+	e.Errors = append(e.Errors, fe)
+}
+
+// Err returns nil without errors, otherwise e with its errors sorted by field
+func (e *ValidationError) Err() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(e.Errors, func(i, j int) bool {
+		return e.Errors[i].Field < e.Errors[j].Field
+	})
+
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		messages[i] = fe.Error()
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, fe := range e.Errors {
+		errs[i] = fe
+	}
+
+	return errs
+}
+
+// Problem returns the problem details answered for the failed request
+func (e *ValidationError) Problem(instance string) Problem {
+	return Problem{
+		Type:     ValidationProblemType,
+		Title:    "Request validation failed",
+		Status:   http.StatusBadRequest,
+		Detail:   fmt.Sprintf("%d field(s) failed validation", len(e.Errors)),
+		Instance: instance,
+		Errors:   e.Errors,
+	}
+}
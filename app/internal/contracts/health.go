@@ -1,6 +1,7 @@
 package contracts
 
 var Health = Contract{
-	Method: "GET",
-	URI:    "/health",
+	Method:   "GET",
+	URI:      "/health",
+	Response: HealthResponse{},
 }
\ No newline at end of file
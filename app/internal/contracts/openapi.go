@@ -0,0 +1,6 @@
+package contracts
+
+var OpenAPI = Contract{
+	Method: "GET",
+	URI:    "/openapi.json",
+}
\ No newline at end of file
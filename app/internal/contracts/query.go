@@ -0,0 +1,119 @@
+package contracts
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+var Pagination = map[string]FieldSpec{
+	"page": {
+		Type: "int",
+		Min:  1,
+	},
+	"page_size": {
+		Type: "int",
+		Min:  1,
+		Max:  100,
+	},
+	"paging": {
+		Type:    "enum",
+		Options: []string{"OFFSET", "CURSOR"},
+	},
+	"cursor": {
+		Type: "string",
+		Min:  1,
+		Max:  512,
+	},
+	"include_total": {
+		Type: "bool",
+	},
+}
+
+// WithPagination returns fields extended with the offset (page, page_size)
+// and keyset (paging=CURSOR, cursor, include_total) pagination params
+func WithPagination(fields map[string]FieldSpec) map[string]FieldSpec {
+	result := make(map[string]FieldSpec, len(fields)+len(Pagination))
+
+	for field, spec := range Pagination {
+		result[field] = spec
+	}
+
+	for field, spec := range fields {
+		result[field] = spec
+	}
+
+	return result
+}
+
+// ValidateQuery validates query-string values against the contract's Query
+// fields, coercing them to the declared types. Unknown keys are rejected and
+// every failing parameter is collected into a *ValidationError.
+func ValidateQuery(values url.Values, c Contract) (map[string]any, error) {
+	result := make(map[string]any, len(values))
+	var verr ValidationError
+
+	for field, vals := range values {
+		spec, ok := c.Query[field]
+		if !ok {
+			verr.Add(field, vals[0], newFieldError(field, ErrUnexpectedField, vals[0], nil, ""))
+			continue
+		}
+
+		if len(vals) > 1 {
+			verr.Add(field, vals, newFieldError(field, ErrInvalidType, vals, map[string]any{"max_items": 1},
+				"expected a single value, got %d", len(vals)))
+			continue
+		}
+
+		val, err := Coerce(vals[0], spec)
+		if err == nil {
+			err = ValidateField(field, val, spec)
+		}
+		if err != nil {
+			verr.Add(field, vals[0], err)
+			continue
+		}
+
+		result[field] = Normalize(val, spec)
+	}
+
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Coerce converts a raw string (URI or query param) into the Go type that
+// ValidateField expects for the spec
+func Coerce(raw string, spec FieldSpec) (any, error) {
+	switch spec.Type {
+        case "int":
+            n, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+            if err != nil {
+                return nil, newFieldError("", ErrInvalidType, raw, map[string]any{"type": "integer"}, "expected integer, got %q", raw)
+            }
+
+            return n, nil
+
+        case "number":
+            n, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+            if err != nil {
+                return nil, newFieldError("", ErrInvalidType, raw, map[string]any{"type": "number"}, "expected number, got %q", raw)
+            }
+
+            return n, nil
+
+        case "bool":
+            b, err := strconv.ParseBool(strings.TrimSpace(raw))
+            if err != nil {
+                return nil, newFieldError("", ErrInvalidType, raw, map[string]any{"type": "boolean"}, "expected boolean, got %q", raw)
+            }
+
+            return b, nil
+
+        default:
+            return raw, nil
+	}
+}
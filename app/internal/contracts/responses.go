@@ -0,0 +1,47 @@
+package contracts
+
+import (
+	"api/internal/domain"
+	"api/pkg/database"
+)
+
+// OneOf describes a response that takes one of several shapes, e.g. list
+// endpoints answering with offset or keyset pages
+type OneOf []any
+
+// DeleteResponse describes the body returned by the delete endpoints
+type DeleteResponse struct {
+	Status string `json:"status"`
+	ID     int    `json:"id"`
+}
+
+// HealthResponse is degraded, still with 200, while reads fall back to the
+// primary because replicas are down or lagging
+type HealthResponse struct {
+	Status   string                   `json:"status"`
+	Replicas []database.ReplicaStatus `json:"replicas,omitempty"`
+}
+// NotEligibleResponse is returned with 422 when a credit application scores
+// below the threshold of its rule set
+type NotEligibleResponse struct {
+	Error    string          `json:"error"`
+	Code     string          `json:"code"`
+	Decision domain.Decision `json:"decision"`
+}
+
+// ProblemContentType is the media type of RFC 7807 problem details
+const ProblemContentType = "application/problem+json"
+
+// ValidationProblemType identifies problems listing invalid request fields
+const ValidationProblemType = "urn:credits:problems:validation"
+
+// Problem is an RFC 7807 problem details body. Errors lists the failing
+// fields of a validation problem.
+type Problem struct {
+	Type     string        `json:"type"`
+	Title    string        `json:"title"`
+	Status   int           `json:"status"`
+	Detail   string        `json:"detail,omitempty"`
+	Instance string        `json:"instance,omitempty"`
+	Errors   []*FieldError `json:"errors,omitempty"`
+}
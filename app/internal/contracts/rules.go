@@ -0,0 +1,279 @@
+package contracts
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Rule types
+const (
+	// RuleCompare holds when Field compared by Op with Other, or with Value
+	// when Other is empty, is true. It is skipped unless both are present.
+	RuleCompare = "compare"
+	// RuleRequired makes Field required, meant to be used with When
+	RuleRequired = "required"
+	// RuleExclusive allows at most one of Fields
+	RuleExclusive = "exclusive"
+	// RuleAtLeastOne requires at least one of Fields
+	RuleAtLeastOne = "at_least_one"
+)
+
+var comparisons = map[string]string{
+	"eq": "==", "neq": "!=", "gt": ">", "gte": ">=", "lt": "<", "lte": "<=",
+}
+
+// Rule checks several fields of a request together. It runs on the
+// normalized values after every field passed its FieldSpec and is skipped
+// when one of its fields already failed.
+type Rule struct {
+	Type   string
+	Field  string
+	Op     string
+	Other  string
+	Value  any
+	Fields []string
+
+	// When limits the rule to requests meeting the condition
+	When *Condition
+}
+
+// Condition is met when Field is present and equals Value
+type Condition struct {
+	Field string
+	Value any
+}
+
+func (c *Condition) met(values map[string]any) bool {
+	if c == nil {
+		return true
+	}
+
+	val, ok := values[c.Field]
+	return ok && equalValues(val, c.Value)
+}
+
+func (c *Condition) String() string {
+	return fmt.Sprintf("when %s is %v", c.Field, c.Value)
+}
+
+func (c *Condition) constraints(into map[string]any) map[string]any {
+	if c != nil {
+		into["when"] = map[string]any{c.Field: c.Value}
+	}
+
+	return into
+}
+
+// fields are all fields the rule reads
+func (r Rule) fields() []string {
+	fields := slices.Clone(r.Fields)
+	for _, field := range []string{r.Field, r.Other} {
+		if field != "" {
+			fields = append(fields, field)
+		}
+	}
+	if r.When != nil {
+		fields = append(fields, r.When.Field)
+	}
+
+	return fields
+}
+
+// String describes the rule, e.g. for the OpenAPI document
+func (r Rule) String() string {
+	var s string
+
+	switch r.Type {
+	case RuleCompare:
+		against := fmt.Sprint(r.Value)
+		if r.Other != "" {
+			against = r.Other
+		}
+		s = fmt.Sprintf("%s %s %s", r.Field, comparisons[r.Op], against)
+	case RuleRequired:
+		s = r.Field + " is required"
+	case RuleExclusive:
+		s = "at most one of " + strings.Join(r.Fields, ", ")
+	case RuleAtLeastOne:
+		s = "at least one of " + strings.Join(r.Fields, ", ")
+	default:
+		s = r.Type
+	}
+
+	if r.When != nil {
+		s += " " + r.When.String()
+	}
+
+	return s
+}
+
+// Check returns the field errors of values breaking the rule
+func (r Rule) Check(values map[string]any) []*FieldError {
+	if !r.When.met(values) {
+		return nil
+	}
+
+	switch r.Type {
+	case RuleCompare:
+		return r.compare(values)
+
+	case RuleRequired:
+		if _, ok := values[r.Field]; ok {
+			return nil
+		}
+
+		if r.When == nil {
+			return []*FieldError{newFieldError(r.Field, ErrRequired, nil, nil, "")}
+		}
+		return []*FieldError{newFieldError(r.Field, ErrRequired, nil, r.When.constraints(map[string]any{}), "%s", r.When)}
+
+	case RuleExclusive:
+		var present []string
+		for _, field := range r.Fields {
+			if _, ok := values[field]; ok {
+				present = append(present, field)
+			}
+		}
+		if len(present) < 2 {
+			return nil
+		}
+
+		errs := make([]*FieldError, 0, len(present))
+		for _, field := range present {
+			errs = append(errs, newFieldError(field, ErrMutuallyExclusive, values[field],
+				r.When.constraints(map[string]any{"exclusive": r.Fields}),
+				"only one of %s may be set", strings.Join(r.Fields, ", ")))
+		}
+		return errs
+
+	case RuleAtLeastOne:
+		for _, field := range r.Fields {
+			if _, ok := values[field]; ok {
+				return nil
+			}
+		}
+
+		errs := make([]*FieldError, 0, len(r.Fields))
+		for _, field := range r.Fields {
+			errs = append(errs, newFieldError(field, ErrAtLeastOne, nil,
+				r.When.constraints(map[string]any{"at_least_one_of": r.Fields}),
+				"set at least one of %s", strings.Join(r.Fields, ", ")))
+		}
+		return errs
+
+	default:
+		return []*FieldError{newFieldError(r.Field, ErrUnsupportedType, nil, nil, "rule %q", r.Type)}
+	}
+}
+
+func (r Rule) compare(values map[string]any) []*FieldError {
+	val, ok := values[r.Field]
+	if !ok {
+		return nil
+	}
+
+	other, against := r.Value, fmt.Sprint(r.Value)
+	constraints := map[string]any{"operator": r.Op, "value": r.Value}
+	if r.Other != "" {
+		if other, ok = values[r.Other]; !ok {
+			return nil
+		}
+		against = fmt.Sprintf("%s (%v)", r.Other, other)
+		constraints = map[string]any{"operator": r.Op, "field": r.Other, "value": other}
+	}
+
+	symbol, known := comparisons[r.Op]
+	holds, ok := compareValues(val, r.Op, other)
+	if !known || !ok {
+		return []*FieldError{newFieldError(r.Field, ErrUnsupportedType, val, nil, "cannot compare %T %s %T", val, r.Op, other)}
+	}
+	if holds {
+		return nil
+	}
+
+	detail := fmt.Sprintf("must be %s %s", symbol, against)
+	if r.When != nil {
+		detail += " " + r.When.String()
+	}
+
+	return []*FieldError{newFieldError(r.Field, ErrComparison, val, r.When.constraints(constraints), "%s", detail)}
+}
+
+// compareValues compares numbers of any type or strings, e.g. dates. ok is
+// false when the values cannot be compared.
+func compareValues(a any, op string, b any) (holds, ok bool) {
+	var order int
+
+	x, xNum := toFloat(a)
+	y, yNum := toFloat(b)
+	sa, aStr := a.(string)
+	sb, bStr := b.(string)
+
+	switch {
+	case xNum && yNum:
+		order = cmp.Compare(x, y)
+	case aStr && bStr:
+		order = strings.Compare(sa, sb)
+	default:
+		return false, false
+	}
+
+	switch op {
+	case "eq":
+		return order == 0, true
+	case "neq":
+		return order != 0, true
+	case "gt":
+		return order > 0, true
+	case "gte":
+		return order >= 0, true
+	case "lt":
+		return order < 0, true
+	case "lte":
+		return order <= 0, true
+	default:
+		return false, false
+	}
+}
+
+func equalValues(a, b any) bool {
+	if holds, ok := compareValues(a, "eq", b); ok {
+		return holds
+	}
+
+	return a == b
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	default:
+		return 0, false
+	}
+}
+
+// checkRules runs the rules of c on validated values, leaving out rules that
+// read a field which already failed
+func checkRules(c Contract, values map[string]any, verr *ValidationError) {
+	failed := make(map[string]bool, len(verr.Errors))
+	for _, fe := range verr.Errors {
+		failed[fe.Field] = true
+	}
+
+	for _, rule := range c.Rules {
+		if slices.ContainsFunc(rule.fields(), func(field string) bool { return failed[field] }) {
+			continue
+		}
+
+		for _, fe := range rule.Check(values) {
+			verr.Add(fe.Field, fe.Value, fe)
+		}
+	}
+}
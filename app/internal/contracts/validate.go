@@ -2,8 +2,9 @@ package contracts
 
 import (
 	"errors"
-	"fmt"
+	"net/url"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 	"unicode/utf8"
@@ -22,6 +23,12 @@ var (
 	ErrTooSmall        = errors.New("value too small")
 	ErrTooBig          = errors.New("value too big")
 	ErrInvalidUUID     = errors.New("invalid UUID format")
+	ErrInvalidSort     = errors.New("invalid sort field")
+	ErrInvalidURL      = errors.New("invalid URL")
+
+	ErrComparison        = errors.New("comparison failed")
+	ErrMutuallyExclusive = errors.New("mutually exclusive fields")
+	ErrAtLeastOne        = errors.New("at least one field required")
 )
 
 var emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
@@ -32,6 +39,24 @@ type Contract struct {
 	URI      string
 	Required map[string]FieldSpec
 	Optional map[string]FieldSpec
+
+	// Query declares the accepted query-string parameters, all optional
+	Query map[string]FieldSpec
+
+	// Rules check body fields together, e.g. that max_payment is not below
+	// min_payment, once each field is valid on its own
+	Rules []Rule
+
+	// Status is the success status code; defaults to 201 for POST, 200 otherwise
+	Status int
+
+	// Conditional endpoints answer with the ETag of versioned resources and
+	// honour If-Match, failing with 412 when the resource moved on
+	Conditional bool
+
+	// Response is a zero value of the type the handler returns; it is only
+	// used to describe the endpoint in the OpenAPI document
+	Response any
 }
 
 type FieldSpec struct {
@@ -40,7 +65,7 @@ type FieldSpec struct {
 	Max     int
 	MinVal  float64
 	MaxVal  float64
-	Options []string
+	Options []string // allowed values for "enum" and "list" items, sortable keys for "sort"
 }
 
 func (c Contract) URIParams() []string {
@@ -57,19 +82,51 @@ func (c Contract) URIParams() []string {
 	return params
 }
 
+// Body returns the contract fields expected in the request body, i.e.
+// everything except the URI params
+func (c Contract) Body() Contract {
+	params := c.URIParams()
+	if len(params) == 0 {
+		return c
+	}
+
+	body := c
+	body.Required = withoutKeys(c.Required, params)
+	body.Optional = withoutKeys(c.Optional, params)
+
+	return body
+}
+
+func withoutKeys(fields map[string]FieldSpec, keys []string) map[string]FieldSpec {
+	result := make(map[string]FieldSpec, len(fields))
+
+	for field, spec := range fields {
+		if !slices.Contains(keys, field) {
+			result[field] = spec
+		}
+	}
+
+	return result
+}
+
+// Validate checks input against the contract, then its rules, and collects
+// every failing field into a *ValidationError
 func Validate(input map[string]any, c Contract) (map[string]any, error) {
 	result := make(map[string]any)
+	var verr ValidationError
 
 	// required
 	for field, spec := range c.Required {
 		val, exists := input[field]
 
 		if !exists || val == nil {
-			return nil, fmt.Errorf("%w: %s", ErrRequired, field)
+			verr.Add(field, nil, newFieldError(field, ErrRequired, nil, nil, ""))
+			continue
 		}
 
 		if err := ValidateField(field, val, spec); err != nil {
-			return nil, fmt.Errorf("%s: %w", field, err)
+			verr.Add(field, val, err)
+			continue
 		}
 
 		result[field] = Normalize(val, spec)
@@ -84,13 +141,14 @@ func Validate(input map[string]any, c Contract) (map[string]any, error) {
 		}
 
 		if err := ValidateField(field, val, spec); err != nil {
-			return nil, fmt.Errorf("%s: %w", field, err)
+			verr.Add(field, val, err)
+			continue
 		}
 
 		result[field] = Normalize(val, spec)
 	}
 
-	for field := range input {
+	for field, val := range input {
 		if _, isReq := c.Required[field]; isReq {
 			continue
 		}
@@ -99,7 +157,13 @@ func Validate(input map[string]any, c Contract) (map[string]any, error) {
 			continue
 		}
 
-		return nil, fmt.Errorf("%w: %s", ErrUnexpectedField, field)
+		verr.Add(field, val, newFieldError(field, ErrUnexpectedField, val, nil, ""))
+	}
+
+	checkRules(c, result, &verr)
+
+	if err := verr.Err(); err != nil {
+		return nil, err
 	}
 
 	return result, nil
@@ -111,18 +175,18 @@ func ValidateField(field string, value any, spec FieldSpec) error {
             s, ok := value.(string)
 
             if !ok {
-		    	return fmt.Errorf("%w: expected string, got %T", ErrInvalidType, value)
+		    	return newFieldError(field, ErrInvalidType, value, map[string]any{"type": "string"}, "expected string, got %T", value)
             }
 
             s = strings.TrimSpace(s)
             length := utf8.RuneCountInString(s)
 
             if spec.Min > 0 && length < spec.Min {
-			    return fmt.Errorf("%w: min length %d, got %d", ErrTooShort, spec.Min, length)
+			    return newFieldError(field, ErrTooShort, value, map[string]any{"min_length": spec.Min}, "min length %d, got %d", spec.Min, length)
             }
 
             if spec.Max > 0 && length > spec.Max {
-			    return fmt.Errorf("%w: max length %d, got %d", ErrTooLong, spec.Max, length)
+			    return newFieldError(field, ErrTooLong, value, map[string]any{"max_length": spec.Max}, "max length %d, got %d", spec.Max, length)
             }
 
             return nil
@@ -131,13 +195,13 @@ func ValidateField(field string, value any, spec FieldSpec) error {
             s, ok := value.(string)
 
             if !ok {
-		    	return fmt.Errorf("%w: expected string for email, got %T", ErrInvalidType, value)
+		    	return newFieldError(field, ErrInvalidType, value, map[string]any{"type": "string"}, "expected string for email, got %T", value)
             }
 
             s = strings.TrimSpace(s)
 
             if !emailRegex.MatchString(s) {
-			    return fmt.Errorf("%w: %s", ErrInvalidEmail, s)
+			    return newFieldError(field, ErrInvalidEmail, value, map[string]any{"format": "email"}, "%s", s)
             }
 
             return nil
@@ -146,13 +210,13 @@ func ValidateField(field string, value any, spec FieldSpec) error {
             s, ok := value.(string)
 
             if !ok {
-                return fmt.Errorf("%w: expected string for email, got %T", ErrInvalidType, value)
+                return newFieldError(field, ErrInvalidType, value, map[string]any{"type": "string"}, "expected string for uuid, got %T", value)
             }
 
             s = strings.TrimSpace(s)
 
             if !uuidRegex.MatchString(s) {
-                return fmt.Errorf("%w: %s", ErrInvalidUUID, s)
+                return newFieldError(field, ErrInvalidUUID, value, map[string]any{"format": "uuid"}, "%s", s)
             }
 
             return nil
@@ -161,14 +225,14 @@ func ValidateField(field string, value any, spec FieldSpec) error {
             s, ok := value.(string)
 
             if !ok {
-		    	return fmt.Errorf("%w: expected string for date, got %T", ErrInvalidType, value)
+		    	return newFieldError(field, ErrInvalidType, value, map[string]any{"type": "string"}, "expected string for date, got %T", value)
             }
 
             s = strings.TrimSpace(s)
 
             _, err := time.Parse("2006-01-02", s)
             if err != nil {
-			    return fmt.Errorf("%w: expected YYYY-MM-DD format", ErrInvalidDate)
+			    return newFieldError(field, ErrInvalidDate, value, map[string]any{"format": "YYYY-MM-DD"}, "expected YYYY-MM-DD format")
             }
 
             return nil
@@ -178,7 +242,7 @@ func ValidateField(field string, value any, spec FieldSpec) error {
             switch v := value.(type) {
                 case float64:
                     if v != float64(int64(v)) {  // ← добавь эту проверку
-                        return fmt.Errorf("%w: expected integer, got float with fraction %v", ErrInvalidType, v)
+                        return newFieldError(field, ErrInvalidType, value, map[string]any{"type": "integer"}, "expected integer, got float with fraction %v", v)
                     }
                     n = int64(v)
 
@@ -187,15 +251,15 @@ func ValidateField(field string, value any, spec FieldSpec) error {
                 case int:
                     n = int64(v)
                 default:
-                    return fmt.Errorf("%w: expected integer, got %T", ErrInvalidType, value)
+                    return newFieldError(field, ErrInvalidType, value, map[string]any{"type": "integer"}, "expected integer, got %T", value)
             }
 
             if spec.Min > 0 && n < int64(spec.Min) {
-                return fmt.Errorf("%w: min %d, got %d", ErrTooSmall, spec.Min, n)
+                return newFieldError(field, ErrTooSmall, value, map[string]any{"min": spec.Min}, "min %d, got %d", spec.Min, n)
             }
 
             if spec.Max > 0 && n > int64(spec.Max) {
-                return fmt.Errorf("%w: max %d, got %d", ErrTooBig, spec.Max, n)
+                return newFieldError(field, ErrTooBig, value, map[string]any{"max": spec.Max}, "max %d, got %d", spec.Max, n)
             }
 
             return nil
@@ -211,15 +275,15 @@ func ValidateField(field string, value any, spec FieldSpec) error {
                     n = float64(v)
 
                 default:
-		    	    return fmt.Errorf("%w: expected number, got %T", ErrInvalidType, value)
+		    	    return newFieldError(field, ErrInvalidType, value, map[string]any{"type": "number"}, "expected number, got %T", value)
             }
 
             if spec.MinVal > 0 && n < spec.MinVal {
-			    return fmt.Errorf("%w: min value %.2f, got %.2f", ErrTooSmall, spec.MinVal, n)
+			    return newFieldError(field, ErrTooSmall, value, map[string]any{"min": spec.MinVal}, "min value %.2f, got %.2f", spec.MinVal, n)
             }
 
             if spec.MaxVal > 0 && n > spec.MaxVal {
-			    return fmt.Errorf("%w: max value %.2f, got %.2f", ErrTooBig, spec.MaxVal, n)
+			    return newFieldError(field, ErrTooBig, value, map[string]any{"max": spec.MaxVal}, "max value %.2f, got %.2f", spec.MaxVal, n)
             }
 
             return nil
@@ -228,7 +292,7 @@ func ValidateField(field string, value any, spec FieldSpec) error {
             s, ok := value.(string)
 
             if !ok {
-			    return fmt.Errorf("%w: expected string for enum, got %T", ErrInvalidType, value)
+			    return newFieldError(field, ErrInvalidType, value, map[string]any{"type": "string"}, "expected string for enum, got %T", value)
             }
 
             s = strings.TrimSpace(strings.ToUpper(s))
@@ -239,22 +303,129 @@ func ValidateField(field string, value any, spec FieldSpec) error {
                 }
             }
 
-		    return fmt.Errorf("%w: %s (allowed: %s)", ErrInvalidEnum, s, strings.Join(spec.Options, ", "))
+		    return newFieldError(field, ErrInvalidEnum, value, map[string]any{"allowed": spec.Options}, "%s (allowed: %s)", s, strings.Join(spec.Options, ", "))
+
+        case "bool":
+            if _, ok := value.(bool); !ok {
+                return newFieldError(field, ErrInvalidType, value, map[string]any{"type": "boolean"}, "expected boolean, got %T", value)
+            }
+
+            return nil
+
+        case "sort":
+            s, ok := value.(string)
+
+            if !ok {
+			    return newFieldError(field, ErrInvalidType, value, map[string]any{"type": "string"}, "expected string for sort, got %T", value)
+            }
+
+            for _, key := range strings.Split(s, ",") {
+                key = strings.TrimPrefix(strings.TrimSpace(strings.ToLower(key)), "-")
+
+                if !slices.Contains(spec.Options, key) {
+                    return newFieldError(field, ErrInvalidSort, value, map[string]any{"allowed": spec.Options}, "%q (allowed: %s)", key, strings.Join(spec.Options, ", "))
+                }
+            }
+
+            return nil
+
+        case "url":
+            s, ok := value.(string)
+
+            if !ok {
+			    return newFieldError(field, ErrInvalidType, value, map[string]any{"type": "string"}, "expected string for url, got %T", value)
+            }
+
+            u, err := url.Parse(strings.TrimSpace(s))
+            if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+                return newFieldError(field, ErrInvalidURL, value, map[string]any{"format": "http(s) URL"}, "expected absolute http(s) URL")
+            }
+
+            if spec.Max > 0 && len(s) > spec.Max {
+			    return newFieldError(field, ErrTooLong, value, map[string]any{"max_length": spec.Max}, "max length %d, got %d", spec.Max, len(s))
+            }
+
+            return nil
+
+        case "list":
+            items, ok := value.([]any)
+
+            if !ok {
+			    return newFieldError(field, ErrInvalidType, value, map[string]any{"type": "array"}, "expected list, got %T", value)
+            }
+
+            if spec.Min > 0 && len(items) < spec.Min {
+			    return newFieldError(field, ErrTooShort, value, map[string]any{"min_items": spec.Min}, "min items %d, got %d", spec.Min, len(items))
+            }
+
+            if spec.Max > 0 && len(items) > spec.Max {
+			    return newFieldError(field, ErrTooLong, value, map[string]any{"max_items": spec.Max}, "max items %d, got %d", spec.Max, len(items))
+            }
+
+            for _, item := range items {
+                s, ok := item.(string)
+                if !ok {
+			        return newFieldError(field, ErrInvalidType, value, map[string]any{"type": "string"}, "expected string items, got %T", item)
+                }
+
+                if !slices.Contains(spec.Options, s) {
+		            return newFieldError(field, ErrInvalidEnum, value, map[string]any{"allowed": spec.Options}, "%s (allowed: %s)", s, strings.Join(spec.Options, ", "))
+                }
+            }
+
+            return nil
 
         default:
-		    return fmt.Errorf("%w: %q for field %s", ErrUnsupportedType, spec.Type, field)
+		    return newFieldError(field, ErrUnsupportedType, value, nil, "%q for field %s", spec.Type, field)
 	}
 }
 
 func Normalize(value any, spec FieldSpec) any {
 	switch spec.Type {
-        case "string","enum":
+        case "string":
 		    return strings.TrimSpace(value.(string))
 
+        case "enum":
+            return strings.TrimSpace(strings.ToUpper(value.(string)))
+
+        case "sort":
+            keys := strings.Split(value.(string), ",")
+            for i, key := range keys {
+                keys[i] = strings.TrimSpace(strings.ToLower(key))
+            }
+
+            return strings.Join(keys, ",")
+
         case "email", "uuid", "date":
 		    return strings.TrimSpace(strings.ToLower(value.(string)))
 
-        case "int", "number":
+        case "url":
+		    return strings.TrimSpace(value.(string))
+
+        case "list":
+            items := value.([]any)
+            result := make([]string, 0, len(items))
+            for _, item := range items {
+                if s := item.(string); !slices.Contains(result, s) {
+                    result = append(result, s)
+                }
+            }
+
+            return result
+
+        case "int":
+            switch v := value.(type) {
+                case float64:
+                    return int(v)
+
+                case int64:
+                    return int(v)
+
+                default:
+                    return value
+            }
+
+        case "number":
             switch v := value.(type) {
                 case float64:
                     return v
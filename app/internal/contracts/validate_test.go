@@ -3,6 +3,7 @@ package contracts
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"reflect"
 	"testing"
 	"strings"
@@ -36,7 +37,7 @@ func TestValidate(t *testing.T) {
 					"name": {Type: "string"},
 				},
 			},
-			wantErr: fmt.Errorf("%w: name", ErrRequired),
+			wantErr: ErrRequired,
 			want:    nil,
 		},
 		{
@@ -98,6 +99,91 @@ func TestValidate(t *testing.T) {
 	}
 }
 
+func TestValidateCollectsErrors(t *testing.T) {
+	contract := Contract{
+		Required: map[string]FieldSpec{
+			"name":  {Type: "string", Min: 2, Max: 100},
+			"type":  {Type: "enum", Options: []string{"PRIVATE", "GOVERNMENT"}},
+			"email": {Type: "email"},
+		},
+		Optional: map[string]FieldSpec{
+			"term_months": {Type: "int", Min: 1, Max: 360},
+		},
+	}
+
+	input := map[string]any{
+		"name":        "T",
+		"type":        "BANANA",
+		"term_months": 400.0,
+		"extra":       true,
+	}
+
+	_, err := Validate(input, contract)
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Validate() error = %v, want *ValidationError", err)
+	}
+
+	tests := []struct {
+		field       string
+		code        string
+		constraints map[string]any
+		value       any
+		sentinel    error
+	}{
+		{"email", "required", nil, nil, ErrRequired},
+		{"extra", "unexpected_field", nil, true, ErrUnexpectedField},
+		{"name", "too_short", map[string]any{"min_length": 2}, "T", ErrTooShort},
+		{"term_months", "too_big", map[string]any{"max": 360}, 400.0, ErrTooBig},
+		{"type", "invalid_enum", map[string]any{"allowed": []string{"PRIVATE", "GOVERNMENT"}}, "BANANA", ErrInvalidEnum},
+	}
+
+	if len(verr.Errors) != len(tests) {
+		t.Fatalf("Errors = %v, want %d", verr.Errors, len(tests))
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			got := verr.Errors[i]
+
+			if got.Field != tt.field || got.Code != tt.code {
+				t.Errorf("Errors[%d] = %s/%s, want %s/%s", i, got.Field, got.Code, tt.field, tt.code)
+			}
+			if !reflect.DeepEqual(got.Constraints, tt.constraints) {
+				t.Errorf("Constraints = %v, want %v", got.Constraints, tt.constraints)
+			}
+			if !reflect.DeepEqual(got.Value, tt.value) {
+				t.Errorf("Value = %v, want %v", got.Value, tt.value)
+			}
+			if !errors.Is(got, tt.sentinel) || !errors.Is(err, tt.sentinel) {
+				t.Errorf("errors.Is(%v, %v) = false", got, tt.sentinel)
+			}
+		})
+	}
+}
+
+func TestValidateQueryCollectsErrors(t *testing.T) {
+	contract := Contract{Query: WithPagination(nil)}
+
+	_, err := ValidateQuery(url.Values{"page": {"x"}, "page_size": {"0"}, "unknown": {"1"}}, contract)
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("ValidateQuery() error = %v, want *ValidationError", err)
+	}
+
+	var codes []string
+	for _, fe := range verr.Errors {
+		codes = append(codes, fe.Field+":"+fe.Code)
+	}
+
+	want := []string{"page:invalid_type", "page_size:too_small", "unknown:unexpected_field"}
+	if !reflect.DeepEqual(codes, want) {
+		t.Errorf("errors = %v, want %v", codes, want)
+	}
+}
+
 func TestValidateField(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -309,6 +395,98 @@ func TestValidateField(t *testing.T) {
 			wantErr: ErrInvalidType,
 		},
 
+		// bool
+		{
+			name:  "valid bool",
+			field: "include_total",
+			value: true,
+			spec:  FieldSpec{Type: "bool"},
+			wantErr: nil,
+		},
+		{
+			name:  "bool invalid type",
+			field: "include_total",
+			value: "yes",
+			spec:  FieldSpec{Type: "bool"},
+			wantErr: ErrInvalidType,
+		},
+
+		// sort
+		{
+			name:  "valid sort",
+			field: "sort",
+			value: "-created_at,name",
+			spec:  FieldSpec{Type: "sort", Options: []string{"name", "created_at"}},
+			wantErr: nil,
+		},
+		{
+			name:  "sort unknown key",
+			field: "sort",
+			value: "name,password",
+			spec:  FieldSpec{Type: "sort", Options: []string{"name", "created_at"}},
+			wantErr: ErrInvalidSort,
+		},
+		{
+			name:  "sort invalid type",
+			field: "sort",
+			value: 1,
+			spec:  FieldSpec{Type: "sort", Options: []string{"name"}},
+			wantErr: ErrInvalidType,
+		},
+
+		// url
+		{
+			name:  "valid url",
+			field: "url",
+			value: "https://bank.example.com/hooks",
+			spec:  FieldSpec{Type: "url"},
+			wantErr: nil,
+		},
+		{
+			name:  "url without scheme",
+			field: "url",
+			value: "bank.example.com/hooks",
+			spec:  FieldSpec{Type: "url"},
+			wantErr: ErrInvalidURL,
+		},
+		{
+			name:  "url with other scheme",
+			field: "url",
+			value: "ftp://bank.example.com",
+			spec:  FieldSpec{Type: "url"},
+			wantErr: ErrInvalidURL,
+		},
+
+		// list
+		{
+			name:  "valid list",
+			field: "event_types",
+			value: []any{"A", "B"},
+			spec:  FieldSpec{Type: "list", Min: 1, Options: []string{"A", "B"}},
+			wantErr: nil,
+		},
+		{
+			name:  "empty list",
+			field: "event_types",
+			value: []any{},
+			spec:  FieldSpec{Type: "list", Min: 1, Options: []string{"A"}},
+			wantErr: ErrTooShort,
+		},
+		{
+			name:  "list unknown item",
+			field: "event_types",
+			value: []any{"A", "C"},
+			spec:  FieldSpec{Type: "list", Options: []string{"A", "B"}},
+			wantErr: ErrInvalidEnum,
+		},
+		{
+			name:  "list invalid type",
+			field: "event_types",
+			value: "A",
+			spec:  FieldSpec{Type: "list", Options: []string{"A"}},
+			wantErr: ErrInvalidType,
+		},
+
 		// unsupported
 		{
 			name:  "unsupported type",
@@ -390,6 +568,18 @@ func TestNormalize(t *testing.T) {
 			spec:  FieldSpec{Type: "enum"},
 			want:  "PRIVATE",
 		},
+		{
+			name:  "normalize sort",
+			value: " -Created_At, name ",
+			spec:  FieldSpec{Type: "sort"},
+			want:  "-created_at,name",
+		},
+		{
+			name:  "normalize list drops duplicates",
+			value: []any{"A", "B", "A"},
+			spec:  FieldSpec{Type: "list"},
+			want:  []string{"A", "B"},
+		},
 		{
 			name:  "normalize unknown type",
 			value: "value",
@@ -406,4 +596,225 @@ func TestNormalize(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestValidateQuery(t *testing.T) {
+	contract := Contract{
+		Query: WithPagination(map[string]FieldSpec{
+			"type": {Type: "enum", Options: []string{"PRIVATE", "GOVERNMENT"}},
+			"rate": {Type: "number", MaxVal: 10},
+			"from": {Type: "date"},
+		}),
+	}
+
+	tests := []struct {
+		name    string
+		query   url.Values
+		wantErr error
+		want    map[string]any
+	}{
+		{
+			name:    "coerces declared params",
+			query:   url.Values{"page": {"2"}, "page_size": {"50"}, "type": {"private"}, "rate": {"2.5"}, "from": {"2026-01-01"}},
+			wantErr: nil,
+			want:    map[string]any{"page": 2, "page_size": 50, "type": "PRIVATE", "rate": 2.5, "from": "2026-01-01"},
+		},
+		{
+			name:    "empty query",
+			query:   url.Values{},
+			wantErr: nil,
+			want:    map[string]any{},
+		},
+		{
+			name:    "unknown param",
+			query:   url.Values{"sort": {"name"}},
+			wantErr: ErrUnexpectedField,
+		},
+		{
+			name:    "int not a number",
+			query:   url.Values{"page": {"two"}},
+			wantErr: ErrInvalidType,
+		},
+		{
+			name:    "int below min",
+			query:   url.Values{"page": {"0"}},
+			wantErr: ErrTooSmall,
+		},
+		{
+			name:    "int above max",
+			query:   url.Values{"page_size": {"101"}},
+			wantErr: ErrTooBig,
+		},
+		{
+			name:    "invalid enum",
+			query:   url.Values{"type": {"corp"}},
+			wantErr: ErrInvalidEnum,
+		},
+		{
+			name:    "invalid date",
+			query:   url.Values{"from": {"01/01/2026"}},
+			wantErr: ErrInvalidDate,
+		},
+		{
+			name:    "keyset params",
+			query:   url.Values{"paging": {"cursor"}, "cursor": {"abc.def"}, "include_total": {"true"}},
+			wantErr: nil,
+			want:    map[string]any{"paging": "CURSOR", "cursor": "abc.def", "include_total": true},
+		},
+		{
+			name:    "bool not a boolean",
+			query:   url.Values{"include_total": {"maybe"}},
+			wantErr: ErrInvalidType,
+		},
+		{
+			name:    "repeated param",
+			query:   url.Values{"page": {"1", "2"}},
+			wantErr: ErrInvalidType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ValidateQuery(tt.query, contract)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateQuery() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidateQuery() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateRules(t *testing.T) {
+	credit := Contract{
+		Optional: map[string]FieldSpec{
+			"min_payment": {Type: "number"},
+			"max_payment": {Type: "number"},
+			"term_months": {Type: "int", Min: 1, Max: 360},
+			"credit_type": {Type: "enum", Options: []string{"AUTO", "MORTGAGE"}},
+			"collateral":  {Type: "string"},
+			"iban":        {Type: "string"},
+			"card":        {Type: "string"},
+		},
+		Rules: []Rule{
+			{Type: RuleCompare, Field: "max_payment", Op: "gte", Other: "min_payment"},
+			{Type: RuleCompare, Field: "term_months", Op: "gte", Value: 60, When: &Condition{Field: "credit_type", Value: "MORTGAGE"}},
+			{Type: RuleRequired, Field: "collateral", When: &Condition{Field: "credit_type", Value: "MORTGAGE"}},
+			{Type: RuleExclusive, Fields: []string{"iban", "card"}},
+			{Type: RuleAtLeastOne, Fields: []string{"min_payment", "max_payment", "term_months", "credit_type"}},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		input     map[string]any
+		wantErr   error
+		wantCodes []string
+	}{
+		{
+			name:  "all rules hold",
+			input: map[string]any{"min_payment": 100.0, "max_payment": 500.0, "term_months": 120.0, "credit_type": "mortgage", "collateral": "house"},
+		},
+		{
+			name:      "max below min",
+			input:     map[string]any{"min_payment": 500.0, "max_payment": 100.0},
+			wantErr:   ErrComparison,
+			wantCodes: []string{"max_payment:comparison_failed"},
+		},
+		{
+			name:  "equal payments",
+			input: map[string]any{"min_payment": 100, "max_payment": 100.0},
+		},
+		{
+			name:  "comparison skipped without the other field",
+			input: map[string]any{"max_payment": 100.0},
+		},
+		{
+			name:      "short mortgage",
+			input:     map[string]any{"term_months": 36.0, "credit_type": "MORTGAGE", "collateral": "house"},
+			wantErr:   ErrComparison,
+			wantCodes: []string{"term_months:comparison_failed"},
+		},
+		{
+			name:  "short auto credit",
+			input: map[string]any{"term_months": 36.0, "credit_type": "AUTO"},
+		},
+		{
+			name:      "conditional required",
+			input:     map[string]any{"credit_type": "MORTGAGE"},
+			wantErr:   ErrRequired,
+			wantCodes: []string{"collateral:required"},
+		},
+		{
+			name:      "mutually exclusive",
+			input:     map[string]any{"credit_type": "AUTO", "iban": "DE89", "card": "4111"},
+			wantErr:   ErrMutuallyExclusive,
+			wantCodes: []string{"card:mutually_exclusive", "iban:mutually_exclusive"},
+		},
+		{
+			name:      "none of at least one",
+			input:     map[string]any{},
+			wantErr:   ErrAtLeastOne,
+			wantCodes: []string{"credit_type:at_least_one_required", "max_payment:at_least_one_required", "min_payment:at_least_one_required", "term_months:at_least_one_required"},
+		},
+		{
+			name:      "rule skipped for a failing field",
+			input:     map[string]any{"min_payment": "a lot", "max_payment": 100.0},
+			wantErr:   ErrInvalidType,
+			wantCodes: []string{"min_payment:invalid_type"},
+		},
+		{
+			name:      "field and rule errors together",
+			input:     map[string]any{"min_payment": 500.0, "max_payment": 100.0, "term_months": 0.0},
+			wantErr:   ErrComparison,
+			wantCodes: []string{"max_payment:comparison_failed", "term_months:too_small"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Validate(tt.input, credit)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				return
+			}
+
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("Validate() error = %v, want *ValidationError", err)
+			}
+
+			var codes []string
+			for _, fe := range verr.Errors {
+				codes = append(codes, fe.Field+":"+fe.Code)
+			}
+			if !reflect.DeepEqual(codes, tt.wantCodes) {
+				t.Errorf("errors = %v, want %v", codes, tt.wantCodes)
+			}
+		})
+	}
+}
+
+func TestRuleConstraints(t *testing.T) {
+	rule := Rule{Type: RuleCompare, Field: "term_months", Op: "gte", Value: 60, When: &Condition{Field: "credit_type", Value: "MORTGAGE"}}
+
+	errs := rule.Check(map[string]any{"term_months": 12, "credit_type": "MORTGAGE"})
+	if len(errs) != 1 {
+		t.Fatalf("Check() = %v, want one error", errs)
+	}
+
+	want := map[string]any{"operator": "gte", "value": 60, "when": map[string]any{"credit_type": "MORTGAGE"}}
+	if !reflect.DeepEqual(errs[0].Constraints, want) {
+		t.Errorf("Constraints = %v, want %v", errs[0].Constraints, want)
+	}
+	if errs[0].Value != 12 {
+		t.Errorf("Value = %v, want the rejected 12", errs[0].Value)
+	}
+	if got := errs[0].Error(); got != "term_months: comparison failed: must be >= 60 when credit_type is MORTGAGE" {
+		t.Errorf("Error() = %q", got)
+	}
+}
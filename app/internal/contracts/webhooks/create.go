@@ -0,0 +1,32 @@
+package webhooks
+
+import (
+	"api/internal/contracts"
+	"api/internal/domain"
+)
+
+var Create = contracts.Contract{
+	Method: "POST",
+	URI:    "/banks/{id}/webhooks",
+	Required: map[string]contracts.FieldSpec{
+		"id": {
+			Type: "int",
+			Min:  1,
+		},
+		"url": {
+			Type: "url",
+			Max:  2048,
+		},
+		"event_types": {
+			Type:    "list",
+			Min:     1,
+			Options: domain.WebhookEventTypes,
+		},
+		"secret": {
+			Type: "string",
+			Min:  16,
+			Max:  256,
+		},
+	},
+	Response: domain.Webhook{},
+}
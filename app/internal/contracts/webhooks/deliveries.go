@@ -0,0 +1,23 @@
+package webhooks
+
+import (
+	"api/internal/contracts"
+	"api/internal/domain"
+	"api/pkg/repository"
+)
+
+var Deliveries = contracts.Contract{
+	Method: "GET",
+	URI:    "/webhooks/{id}/deliveries",
+	Required: map[string]contracts.FieldSpec{
+		"id": {
+			Type: "int",
+			Min:  1,
+		},
+	},
+	Query: contracts.WithPagination(nil),
+	Response: contracts.OneOf{
+		repository.PaginatedResult[domain.WebhookDelivery]{},
+		repository.CursorResult[domain.WebhookDelivery]{},
+	},
+}
@@ -0,0 +1,18 @@
+package webhooks
+
+import (
+	"api/internal/contracts"
+	"api/internal/domain"
+)
+
+var Delivery = contracts.Contract{
+	Method: "GET",
+	URI:    "/webhook-deliveries/{id}",
+	Required: map[string]contracts.FieldSpec{
+		"id": {
+			Type: "int",
+			Min:  1,
+		},
+	},
+	Response: domain.WebhookDelivery{},
+}
@@ -0,0 +1,18 @@
+package webhooks
+
+import (
+	"api/internal/contracts"
+	"api/internal/domain"
+)
+
+var List = contracts.Contract{
+	Method: "GET",
+	URI:    "/banks/{id}/webhooks",
+	Required: map[string]contracts.FieldSpec{
+		"id": {
+			Type: "int",
+			Min:  1,
+		},
+	},
+	Response: []domain.Webhook{},
+}
@@ -0,0 +1,21 @@
+package webhooks
+
+import (
+	"net/http"
+
+	"api/internal/contracts"
+	"api/internal/domain"
+)
+
+var Redeliver = contracts.Contract{
+	Method: "POST",
+	URI:    "/webhook-deliveries/{id}/redeliver",
+	Required: map[string]contracts.FieldSpec{
+		"id": {
+			Type: "int",
+			Min:  1,
+		},
+	},
+	Status:   http.StatusAccepted,
+	Response: domain.WebhookDelivery{},
+}
@@ -7,4 +7,9 @@ type Bank struct {
 	Name      string    `json:"name"`
 	Type      string    `json:"type"` // PRIVATE |   GOVERNMENT
 	CreatedAt time.Time `json:"created_at"`
+	Version   int       `json:"version"`
+}
+
+func (b Bank) CurrentVersion() int {
+	return b.Version
 }
\ No newline at end of file
@@ -9,4 +9,9 @@ type Client struct {
 	BirthDate string    `json:"birth_date"`
 	Country   string    `json:"country"`
 	CreatedAt time.Time `json:"created_at"`
+	Version   int       `json:"version"`
+}
+
+func (c Client) CurrentVersion() int {
+	return c.Version
 }
\ No newline at end of file
@@ -1,6 +1,40 @@
 package domain
 
-import "time"
+import (
+	"slices"
+	"time"
+)
+
+const (
+	CreditPending     = "PENDING"
+	CreditUnderReview = "UNDER_REVIEW"
+	CreditApproved    = "APPROVED"
+	CreditRejected    = "REJECTED"
+	CreditDisbursed   = "DISBURSED"
+	CreditActive      = "ACTIVE"
+	CreditClosed      = "CLOSED"
+	CreditDefaulted   = "DEFAULTED"
+)
+
+// MortgageMinTermMonths is the shortest term of a MORTGAGE credit
+const MortgageMinTermMonths = 60
+
+// CreditStatuses lists every lifecycle status in order
+var CreditStatuses = []string{
+	CreditPending, CreditUnderReview, CreditApproved, CreditRejected,
+	CreditDisbursed, CreditActive, CreditClosed, CreditDefaulted,
+}
+
+// creditTransitions is the credit lifecycle:
+// PENDING -> UNDER_REVIEW -> APPROVED | REJECTED
+// APPROVED -> DISBURSED -> ACTIVE -> CLOSED | DEFAULTED
+var creditTransitions = map[string][]string{
+	CreditPending:     {CreditUnderReview},
+	CreditUnderReview: {CreditApproved, CreditRejected},
+	CreditApproved:    {CreditDisbursed},
+	CreditDisbursed:   {CreditActive},
+	CreditActive:      {CreditClosed, CreditDefaulted},
+}
 
 type Credit struct {
 	ID         int    `json:"id"`
@@ -12,4 +46,54 @@ type Credit struct {
 	CreditType string    `json:"credit_type"`
 	Status     string    `json:"status"`
 	CreatedAt  time.Time `json:"created_at"`
-}
\ No newline at end of file
+	StatusReason *string `json:"status_reason,omitempty"`
+	RuleSetID      *int `json:"rule_set_id,omitempty"`
+	RuleSetVersion *int `json:"rule_set_version,omitempty"`
+	Version        int  `json:"version"`
+}
+
+func (c Credit) CurrentVersion() int {
+	return c.Version
+}
+
+// CheckTerms enforces the rules between the terms of the credit. An update
+// has to check them on the merged credit, the request alone may only carry
+// one side of a rule.
+func (c Credit) CheckTerms() error {
+	if c.MaxPayment < c.MinPayment {
+		return ErrInconsistentTerms.Explain("max_payment %v is below min_payment %v", c.MaxPayment, c.MinPayment)
+	}
+
+	if c.CreditType == "MORTGAGE" && c.TermMonths < MortgageMinTermMonths {
+		return ErrInconsistentTerms.Explain("term_months %d is below %d for a MORTGAGE", c.TermMonths, MortgageMinTermMonths)
+	}
+
+	return nil
+}
+
+// CheckEditable rejects changing the terms once the credit left review,
+// they were what its eligibility and approval were decided on
+func (c Credit) CheckEditable() error {
+	if c.Status != CreditPending && c.Status != CreditUnderReview {
+		return ErrInvalidTransition.Explain("a %s credit cannot be updated", c.Status)
+	}
+
+	return nil
+}
+
+// CanTransition reports whether the lifecycle allows moving from one status to another
+func CanTransition(from, to string) bool {
+	return slices.Contains(creditTransitions[from], to)
+}
+
+// TransitionTo moves the credit to the given status, enforcing the lifecycle
+func (c *Credit) TransitionTo(status string, reason *string) error {
+	if !CanTransition(c.Status, status) {
+		return ErrInvalidTransition.Explain("%s -> %s", c.Status, status)
+	}
+
+	c.Status = status
+	c.StatusReason = reason
+
+	return nil
+}
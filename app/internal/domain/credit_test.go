@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestCreditTransitionTo(t *testing.T) {
+	tests := []struct {
+		from    string
+		to      string
+		wantErr error
+	}{
+		{CreditPending, CreditUnderReview, nil},
+		{CreditUnderReview, CreditApproved, nil},
+		{CreditUnderReview, CreditRejected, nil},
+		{CreditApproved, CreditDisbursed, nil},
+		{CreditDisbursed, CreditActive, nil},
+		{CreditActive, CreditClosed, nil},
+		{CreditActive, CreditDefaulted, nil},
+
+		{CreditPending, CreditApproved, ErrInvalidTransition},
+		{CreditRejected, CreditApproved, ErrInvalidTransition},
+		{CreditApproved, CreditApproved, ErrInvalidTransition},
+		{CreditApproved, CreditRejected, ErrInvalidTransition},
+		{CreditClosed, CreditActive, ErrInvalidTransition},
+		{CreditDefaulted, CreditClosed, ErrInvalidTransition},
+		{CreditPending, "UNKNOWN", ErrInvalidTransition},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+"->"+tt.to, func(t *testing.T) {
+			reason := "because"
+			credit := Credit{Status: tt.from}
+
+			err := credit.TransitionTo(tt.to, &reason)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("TransitionTo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			want := tt.to
+			if tt.wantErr != nil {
+				want = tt.from
+			}
+			if credit.Status != want {
+				t.Errorf("status = %s, want %s", credit.Status, want)
+			}
+		})
+	}
+}
+
+func TestCreditCheckTerms(t *testing.T) {
+	tests := []struct {
+		name    string
+		credit  Credit
+		wantErr error
+	}{
+		{"consistent", Credit{MinPayment: 100, MaxPayment: 200, TermMonths: 12, CreditType: "AUTO"}, nil},
+		{"equal payments", Credit{MinPayment: 100, MaxPayment: 100, TermMonths: 12, CreditType: "AUTO"}, nil},
+		{"max below stored min", Credit{MinPayment: 500, MaxPayment: 200, TermMonths: 12, CreditType: "AUTO"}, ErrInconsistentTerms},
+		{"long mortgage", Credit{MinPayment: 100, MaxPayment: 200, TermMonths: 60, CreditType: "MORTGAGE"}, nil},
+		{"short mortgage", Credit{MinPayment: 100, MaxPayment: 200, TermMonths: 24, CreditType: "MORTGAGE"}, ErrInconsistentTerms},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.credit.CheckTerms()
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Fatalf("CheckTerms() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var derr *Error
+			if err != nil && (!errors.As(err, &derr) || derr.Code != "comparison_failed" || derr.Status != http.StatusUnprocessableEntity) {
+				t.Errorf("CheckTerms() error = %#v, want a 422 comparison_failed", err)
+			}
+		})
+	}
+}
+
+func TestCreditCheckEditable(t *testing.T) {
+	for _, status := range CreditStatuses {
+		t.Run(status, func(t *testing.T) {
+			err := Credit{Status: status}.CheckEditable()
+
+			editable := status == CreditPending || status == CreditUnderReview
+			if editable != (err == nil) {
+				t.Fatalf("CheckEditable() error = %v, want editable %v", err, editable)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidTransition) {
+				t.Errorf("CheckEditable() error = %v, want ErrInvalidTransition", err)
+			}
+		})
+	}
+}
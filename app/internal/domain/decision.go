@@ -0,0 +1,48 @@
+package domain
+
+import "time"
+
+// Factor is how one field of the application was scored: the applicant's
+// value, the points it earned, zero included, and the rules that matched
+type Factor struct {
+	Field        string        `json:"field"`
+	Value        any           `json:"value"`
+	Points       int           `json:"points"`
+	MatchedRules []MatchedRule `json:"matched_rules"`
+}
+
+// MatchedRule is an eligibility rule that held and added its points
+type MatchedRule struct {
+	Operator string `json:"operator"`
+	Value    any    `json:"value"`
+	Points   int    `json:"points"`
+}
+
+// Decision records an eligibility evaluation and how its score was reached.
+// CreditID is nil for rejected applications and dry runs.
+type Decision struct {
+	ID             int       `json:"id,omitempty"`
+	CreditID       *int      `json:"credit_id,omitempty"`
+	ClientID       int       `json:"client_id"`
+	BankID         int       `json:"bank_id"`
+	RuleSetID      *int      `json:"rule_set_id,omitempty"`
+	RuleSetVersion int       `json:"rule_set_version"`
+	Score          int       `json:"score"`
+	Threshold      int       `json:"threshold"`
+	Eligible       bool      `json:"eligible"`
+	Factors        []Factor  `json:"factors"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// NotEligibleError carries the decision behind a rejected application
+type NotEligibleError struct {
+	Decision Decision
+}
+
+func (e *NotEligibleError) Error() string {
+	return ErrNotEligible.Error()
+}
+
+func (e *NotEligibleError) Unwrap() error {
+	return ErrNotEligible
+}
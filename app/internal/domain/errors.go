@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Error is a failure the API answers with its Status, a stable Code and a
+// Message safe to show to callers. Detail and the wrapped cause are internal
+// and only end up in the logs.
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	Detail  string
+
+	parent *Error
+	cause  error
+}
+
+// NewError declares a kind of error, errors derived from it with Explain or
+// Wrap still match it with errors.Is
+func NewError(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+func (e *Error) derive() *Error {
+	derived := *e
+	derived.parent = e
+	derived.cause = nil
+
+	return &derived
+}
+
+// Explain returns e with a public explanation appended to its message
+func (e *Error) Explain(format string, args ...any) *Error {
+	derived := e.derive()
+	derived.Message += ": " + fmt.Sprintf(format, args...)
+
+	return derived
+}
+
+// Wrap returns e caused by err. The message stays the same, err is only
+// kept as internal detail.
+func (e *Error) Wrap(err error) *Error {
+	derived := e.derive()
+	derived.cause = err
+	derived.Detail = err.Error()
+
+	return derived
+}
+
+func (e *Error) Error() string {
+	if e.Detail == "" {
+		return e.Message
+	}
+
+	return e.Message + ": " + e.Detail
+}
+
+func (e *Error) Unwrap() []error {
+	var errs []error
+	if e.parent != nil {
+		errs = append(errs, e.parent)
+	}
+	if e.cause != nil {
+		errs = append(errs, e.cause)
+	}
+
+	return errs
+}
+
+var (
+	ErrInvalidInput      = NewError(http.StatusBadRequest, "invalid_input", "invalid input parameters")
+	ErrNotFound          = NewError(http.StatusNotFound, "not_found", "resource not found")
+	ErrAlreadyExists     = NewError(http.StatusConflict, "already_exists", "already exists")
+	ErrForeignKey        = NewError(http.StatusUnprocessableEntity, "invalid_reference", "referenced resource does not exist")
+	ErrNotEligible       = NewError(http.StatusUnprocessableEntity, "not_eligible", "client not eligible for credit")
+	ErrInconsistentTerms = NewError(http.StatusUnprocessableEntity, "comparison_failed", "credit terms are inconsistent")
+	ErrInvalidTransition = NewError(http.StatusConflict, "invalid_transition", "invalid status transition")
+	ErrVersionConflict   = NewError(http.StatusPreconditionFailed, "version_conflict", "resource was modified concurrently")
+
+	// ErrInternal is answered for every error outside the taxonomy
+	ErrInternal = NewError(http.StatusInternalServerError, "internal", "internal server error")
+)
@@ -1,10 +1,17 @@
 package domain
-import "errors"
 
-var (
-	ErrInvalidInput  = errors.New("invalid input parameters")
-	ErrNotFound      = errors.New("resource not found")
-    ErrAlreadyExists = errors.New("already exists")
-    ErrForeignKey    = errors.New("foreign key violation")
-    ErrNotEligible   = errors.New("client not eligible for credit")
-)
\ No newline at end of file
+// Versioned is implemented by entities under optimistic locking, their
+// version is bumped by every update
+type Versioned interface {
+	CurrentVersion() int
+}
+
+// CheckVersion fails with ErrVersionConflict unless entity is at the
+// expected version, zero expects any version
+func CheckVersion(entity Versioned, expected int) error {
+	if expected != 0 && entity.CurrentVersion() != expected {
+		return ErrVersionConflict.Explain("at version %d, expected %d", entity.CurrentVersion(), expected)
+	}
+
+	return nil
+}
\ No newline at end of file
@@ -0,0 +1,148 @@
+package eligibility
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Loader fetches the latest active rule set of every scope
+type Loader interface {
+	LoadActive(ctx context.Context) ([]RuleSet, error)
+}
+
+// Engine keeps the active rule sets in memory and picks the one that applies
+// to a credit application
+type Engine struct {
+	loader  Loader
+	observe func(Result)
+
+	mu   sync.RWMutex
+	sets []RuleSet
+}
+
+func NewEngine(loader Loader) *Engine {
+	return &Engine{loader: loader}
+}
+
+// Reload replaces the in-memory rule sets. Sets that fail validation are
+// skipped, so a bad row cannot take scoring down.
+func (e *Engine) Reload(ctx context.Context) error {
+	sets, err := e.loader.LoadActive(ctx)
+	if err != nil {
+		return err
+	}
+
+	valid := make([]RuleSet, 0, len(sets))
+	for _, set := range sets {
+		if err := set.Validate(); err != nil {
+			slog.Error("skipping eligibility rule set", "id", set.ID, "version", set.Version, "err", err)
+			continue
+		}
+		valid = append(valid, set)
+	}
+
+	e.mu.Lock()
+	e.sets = valid
+	e.mu.Unlock()
+
+	return nil
+}
+
+// Watch reloads the rule sets every interval until ctx is done
+func (e *Engine) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			if err := e.Reload(ctx); err != nil {
+				slog.Error("eligibility rules reload failed", "err", err)
+			}
+		}
+	}
+}
+
+// Select returns the most specific rule set for a bank and credit type:
+// bank + credit type, then bank, then credit type, then the global one
+func (e *Engine) Select(bankID int, creditType string) RuleSet {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	best, bestRank := DefaultRuleSet, -1
+
+	for _, set := range e.sets {
+		if set.BankID != nil && *set.BankID != bankID {
+			continue
+		}
+		if set.CreditType != nil && *set.CreditType != creditType {
+			continue
+		}
+
+		rank := 0
+		if set.BankID != nil {
+			rank += 2
+		}
+		if set.CreditType != nil {
+			rank++
+		}
+
+		if rank > bestRank {
+			best, bestRank = set, rank
+		}
+	}
+
+	return best
+}
+
+// Observe sets fn to be called with every result, e.g. for metrics. It is
+// meant to be set once, before the engine is used.
+func (e *Engine) Observe(fn func(Result)) {
+	e.observe = fn
+}
+
+// Evaluate scores the subject with the rule set that applies to it
+func (e *Engine) Evaluate(bankID int, subject Subject) Result {
+	return e.EvaluateContext(context.Background(), bankID, subject)
+}
+
+// EvaluateContext is Evaluate traced as a child span of ctx
+func (e *Engine) EvaluateContext(ctx context.Context, bankID int, subject Subject) Result {
+	set := e.Select(bankID, subject.CreditType)
+	result := Trace(ctx, set, subject)
+	if e.observe != nil {
+		e.observe(result)
+	}
+
+	return result
+}
+
+// Trace evaluates the subject with set in an eligibility.evaluate span that
+// holds the outcome, with a child span per factor
+func Trace(ctx context.Context, set RuleSet, subject Subject) Result {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "eligibility.evaluate",
+		trace.WithAttributes(
+			attribute.Int("eligibility.rule_set_id", set.ID),
+			attribute.Int("eligibility.rule_set_version", set.Version),
+		),
+	)
+	defer span.End()
+
+	result := set.EvaluateContext(ctx, subject)
+	span.SetAttributes(
+		attribute.Int("eligibility.score", result.Score),
+		attribute.Int("eligibility.threshold", result.Threshold),
+		attribute.Bool("eligibility.eligible", result.Eligible),
+	)
+
+	return result
+}
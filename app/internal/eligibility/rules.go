@@ -0,0 +1,340 @@
+package eligibility
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "api/internal/eligibility"
+
+var ErrInvalidRule = errors.New("invalid eligibility rule")
+
+// Fields a rule can test
+const (
+	FieldAge        = "age"
+	FieldCountry    = "country"
+	FieldBankType   = "bank_type"
+	FieldCreditType = "credit_type"
+	FieldTermMonths = "term_months"
+	FieldMinPayment = "min_payment"
+	FieldMaxPayment = "max_payment"
+)
+
+var fields = []string{
+	FieldAge, FieldCountry, FieldBankType, FieldCreditType,
+	FieldTermMonths, FieldMinPayment, FieldMaxPayment,
+}
+
+var operators = []string{"eq", "neq", "gt", "gte", "lt", "lte", "between", "in", "not_in"}
+
+// Rule awards Points when Field compared with Value by Operator holds.
+// Value is a number or string, a [min, max] pair for "between" and a list
+// for "in" / "not_in".
+type Rule struct {
+	Field    string `json:"field"`
+	Operator string `json:"operator"`
+	Value    any    `json:"value"`
+	Points   int    `json:"points"`
+}
+
+// RuleSet is one version of the scoring rules for a scope. A nil BankID or
+// CreditType matches any bank or credit type.
+type RuleSet struct {
+	ID         int       `json:"id"`
+	Version    int       `json:"version"`
+	BankID     *int      `json:"bank_id,omitempty"`
+	CreditType *string   `json:"credit_type,omitempty"`
+	Threshold  int       `json:"threshold"`
+	Rules      []Rule    `json:"rules"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Subject is what the rules are evaluated against
+type Subject struct {
+	Age        int
+	Country    string
+	BankType   string
+	CreditType string
+	TermMonths int
+	MinPayment float64
+	MaxPayment float64
+}
+
+// Match is a rule that contributed to a score
+type Match struct {
+	Field    string `json:"field"`
+	Operator string `json:"operator"`
+	Value    any    `json:"value"`
+	Points   int    `json:"points"`
+}
+
+// Factor is the evaluation of one field the rules test: the subject's value,
+// the points it earned, zero included, and the rules that matched
+type Factor struct {
+	Field   string  `json:"field"`
+	Value   any     `json:"value"`
+	Points  int     `json:"points"`
+	Matches []Match `json:"matches"`
+}
+
+// Result is the outcome of evaluating a rule set
+type Result struct {
+	RuleSetID      int      `json:"rule_set_id"`
+	RuleSetVersion int      `json:"rule_set_version"`
+	Score          int      `json:"score"`
+	Threshold      int      `json:"threshold"`
+	Eligible       bool     `json:"eligible"`
+	Matches        []Match  `json:"matches"`
+	Factors        []Factor `json:"factors"`
+}
+
+// DefaultRuleSet is used until a rule set has been loaded from the database
+var DefaultRuleSet = RuleSet{
+	ID:        0,
+	Version:   0,
+	Threshold: 50,
+	Rules: []Rule{
+		{Field: FieldAge, Operator: "between", Value: []any{18.0, 70.0}, Points: 35},
+		{Field: FieldAge, Operator: "gt", Value: 70.0, Points: 15},
+		{Field: FieldBankType, Operator: "eq", Value: "PRIVATE", Points: 30},
+		{Field: FieldBankType, Operator: "eq", Value: "GOVERNMENT", Points: 20},
+		{Field: FieldCountry, Operator: "in", Value: []any{"USA", "Canada", "Chili"}, Points: 35},
+		{Field: FieldCountry, Operator: "in", Value: []any{"Mexico", "Brazil", "Panama"}, Points: 20},
+		{Field: FieldCountry, Operator: "not_in", Value: []any{"USA", "Canada", "Chili", "Mexico", "Brazil", "Panama"}, Points: 10},
+	},
+}
+
+// Validate checks fields, operators and value shapes of every rule
+func (rs RuleSet) Validate() error {
+	for i, rule := range rs.Rules {
+		if !slices.Contains(fields, rule.Field) {
+			return fmt.Errorf("%w: rule %d: unknown field %q", ErrInvalidRule, i, rule.Field)
+		}
+
+		if !slices.Contains(operators, rule.Operator) {
+			return fmt.Errorf("%w: rule %d: unknown operator %q", ErrInvalidRule, i, rule.Operator)
+		}
+
+		list, isList := rule.Value.([]any)
+
+		switch rule.Operator {
+		case "between":
+			if !isList || len(list) != 2 {
+				return fmt.Errorf("%w: rule %d: between expects [min, max]", ErrInvalidRule, i)
+			}
+		case "in", "not_in":
+			if !isList {
+				return fmt.Errorf("%w: rule %d: %s expects a list", ErrInvalidRule, i, rule.Operator)
+			}
+		default:
+			if isList {
+				return fmt.Errorf("%w: rule %d: %s expects a single value", ErrInvalidRule, i, rule.Operator)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Evaluate scores the subject: every matching rule adds its points
+func (rs RuleSet) Evaluate(s Subject) Result {
+	return rs.EvaluateContext(context.Background(), s)
+}
+
+// EvaluateContext is Evaluate with a child span of ctx for every factor, i.e.
+// the rules that test one field
+func (rs RuleSet) EvaluateContext(ctx context.Context, s Subject) Result {
+	result := Result{
+		RuleSetID:      rs.ID,
+		RuleSetVersion: rs.Version,
+		Threshold:      rs.Threshold,
+		Matches:        make([]Match, 0, len(rs.Rules)),
+	}
+
+	matched := make([]bool, len(rs.Rules))
+	for _, field := range fields {
+		factor, tested := rs.evaluateFactor(ctx, field, s.value(field), matched)
+		if !tested {
+			continue
+		}
+
+		result.Score += factor.Points
+		result.Factors = append(result.Factors, factor)
+	}
+
+	// matches keep the order of the rules
+	for i, rule := range rs.Rules {
+		if !matched[i] {
+			continue
+		}
+
+		result.Matches = append(result.Matches, rule.match())
+	}
+
+	result.Eligible = result.Score >= rs.Threshold
+
+	return result
+}
+
+// evaluateFactor marks the matching rules of field and returns the factor,
+// tested is false when no rule tests field
+func (rs RuleSet) evaluateFactor(ctx context.Context, field string, actual any, matched []bool) (factor Factor, tested bool) {
+	var span trace.Span
+	rules := 0
+
+	factor = Factor{Field: field, Value: actual, Matches: []Match{}}
+
+	for i, rule := range rs.Rules {
+		if rule.Field != field {
+			continue
+		}
+		if span == nil {
+			_, span = otel.Tracer(tracerName).Start(ctx, "eligibility.factor "+field)
+		}
+
+		rules++
+		if rule.matches(actual) {
+			matched[i] = true
+			factor.Points += rule.Points
+			factor.Matches = append(factor.Matches, rule.match())
+		}
+	}
+
+	if span != nil {
+		span.SetAttributes(
+			attribute.String("eligibility.field", field),
+			attribute.String("eligibility.value", fmt.Sprint(actual)),
+			attribute.Int("eligibility.rules", rules),
+			attribute.Int("eligibility.points", factor.Points),
+		)
+		span.End()
+	}
+
+	return factor, rules > 0
+}
+
+func (r Rule) match() Match {
+	return Match{Field: r.Field, Operator: r.Operator, Value: r.Value, Points: r.Points}
+}
+
+func (s Subject) value(field string) any {
+	switch field {
+	case FieldAge:
+		return float64(s.Age)
+	case FieldCountry:
+		return s.Country
+	case FieldBankType:
+		return s.BankType
+	case FieldCreditType:
+		return s.CreditType
+	case FieldTermMonths:
+		return float64(s.TermMonths)
+	case FieldMinPayment:
+		return s.MinPayment
+	case FieldMaxPayment:
+		return s.MaxPayment
+	default:
+		return nil
+	}
+}
+
+func (r Rule) matches(actual any) bool {
+	switch r.Operator {
+	case "eq":
+		return equal(actual, r.Value)
+	case "neq":
+		return !equal(actual, r.Value)
+	case "gt", "gte", "lt", "lte":
+		cmp, ok := compare(actual, r.Value)
+		if !ok {
+			return false
+		}
+		switch r.Operator {
+		case "gt":
+			return cmp > 0
+		case "gte":
+			return cmp >= 0
+		case "lt":
+			return cmp < 0
+		default:
+			return cmp <= 0
+		}
+	case "between":
+		bounds, _ := r.Value.([]any)
+		if len(bounds) != 2 {
+			return false
+		}
+		low, okLow := compare(actual, bounds[0])
+		high, okHigh := compare(actual, bounds[1])
+		return okLow && okHigh && low >= 0 && high <= 0
+	case "in":
+		return contains(r.Value, actual)
+	case "not_in":
+		return !contains(r.Value, actual)
+	default:
+		return false
+	}
+}
+
+func equal(a, b any) bool {
+	if as, ok := a.(string); ok {
+		bs, ok := b.(string)
+		return ok && strings.EqualFold(as, bs)
+	}
+
+	af, aok := toFloat(a)
+	bf, bok := toFloat(b)
+
+	return aok && bok && af == bf
+}
+
+// compare orders two numbers; ok is false when either is not numeric
+func compare(a, b any) (cmp int, ok bool) {
+	af, aok := toFloat(a)
+	bf, bok := toFloat(b)
+
+	if !aok || !bok {
+		return 0, false
+	}
+
+	switch {
+	case af < bf:
+		return -1, true
+	case af > bf:
+		return 1, true
+	default:
+		return 0, true
+	}
+}
+
+func contains(list, value any) bool {
+	items, _ := list.([]any)
+	for _, item := range items {
+		if equal(value, item) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	default:
+		return 0, false
+	}
+}
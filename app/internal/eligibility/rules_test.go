@@ -0,0 +1,206 @@
+package eligibility
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestRuleSetEvaluate(t *testing.T) {
+	tests := []struct {
+		name      string
+		subject   Subject
+		wantScore int
+		eligible  bool
+	}{
+		{"young private usa", Subject{Age: 30, BankType: "PRIVATE", Country: "USA"}, 100, true},
+		{"senior government brazil", Subject{Age: 75, BankType: "GOVERNMENT", Country: "Brazil"}, 55, true},
+		{"minor other", Subject{Age: 16, BankType: "OTHER", Country: "France"}, 10, false},
+		{"boundary age 70", Subject{Age: 70, BankType: "OTHER", Country: "Mexico"}, 55, true},
+		{"boundary age 18", Subject{Age: 18, BankType: "OTHER", Country: "France"}, 45, false},
+		{"country case insensitive", Subject{Age: 10, BankType: "private", Country: "usa"}, 65, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := DefaultRuleSet.Evaluate(tt.subject)
+
+			if result.Score != tt.wantScore {
+				t.Errorf("Score = %d, want %d (matches %+v)", result.Score, tt.wantScore, result.Matches)
+			}
+			if result.Eligible != tt.eligible {
+				t.Errorf("Eligible = %v, want %v", result.Eligible, tt.eligible)
+			}
+		})
+	}
+}
+
+func TestRuleSetEvaluateFactors(t *testing.T) {
+	result := DefaultRuleSet.Evaluate(Subject{Age: 17, BankType: "OTHER", Country: "France", TermMonths: 12})
+
+	// one factor per field the rules test, in field order, unmatched ones
+	// included with the subject's value
+	want := []Factor{
+		{Field: FieldAge, Value: 17.0, Points: 0, Matches: []Match{}},
+		{Field: FieldCountry, Value: "France", Points: 10, Matches: []Match{DefaultRuleSet.Rules[6].match()}},
+		{Field: FieldBankType, Value: "OTHER", Points: 0, Matches: []Match{}},
+	}
+	if !reflect.DeepEqual(result.Factors, want) {
+		t.Errorf("Factors = %+v, want %+v", result.Factors, want)
+	}
+	if result.Score != 10 {
+		t.Errorf("Score = %d, want the sum of the factors", result.Score)
+	}
+}
+
+func TestRuleSetEvaluateSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	result := Trace(context.Background(), DefaultRuleSet, Subject{Age: 30, BankType: "PRIVATE", Country: "USA"})
+
+	points := make(map[string]int)
+	var parent sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == "eligibility.evaluate" {
+			parent = span
+			continue
+		}
+		for _, attr := range span.Attributes() {
+			if attr.Key == "eligibility.points" {
+				points[span.Name()] = int(attr.Value.AsInt64())
+			}
+		}
+	}
+
+	if parent == nil {
+		t.Fatal("no eligibility.evaluate span")
+	}
+
+	// one span per field the rules test
+	want := map[string]int{
+		"eligibility.factor age":       35,
+		"eligibility.factor bank_type": 30,
+		"eligibility.factor country":   35,
+	}
+	if len(points) != len(want) {
+		t.Errorf("factor spans = %v, want %v", points, want)
+	}
+	for name, p := range want {
+		if points[name] != p {
+			t.Errorf("%s points = %d, want %d", name, points[name], p)
+		}
+	}
+
+	// matches keep the order of the rules
+	if len(result.Matches) != 3 || result.Matches[0].Field != FieldAge || result.Matches[2].Field != FieldCountry {
+		t.Errorf("Matches = %+v", result.Matches)
+	}
+}
+
+func TestRuleMatches(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   Rule
+		actual any
+		want   bool
+	}{
+		{"eq number", Rule{Operator: "eq", Value: 12.0}, 12.0, true},
+		{"neq string", Rule{Operator: "neq", Value: "AUTO"}, "MORTGAGE", true},
+		{"gte equal", Rule{Operator: "gte", Value: 60.0}, 60.0, true},
+		{"lt greater", Rule{Operator: "lt", Value: 60.0}, 61.0, false},
+		{"lte on string", Rule{Operator: "lte", Value: 60.0}, "60", false},
+		{"between inside", Rule{Operator: "between", Value: []any{1.0, 10.0}}, 5.0, true},
+		{"between outside", Rule{Operator: "between", Value: []any{1.0, 10.0}}, 11.0, false},
+		{"in", Rule{Operator: "in", Value: []any{"AUTO", "MORTGAGE"}}, "auto", true},
+		{"not_in", Rule{Operator: "not_in", Value: []any{"AUTO"}}, "MORTGAGE", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.matches(tt.actual); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleSetValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    Rule
+		wantErr error
+	}{
+		{"valid", Rule{Field: FieldAge, Operator: "gt", Value: 18.0}, nil},
+		{"unknown field", Rule{Field: "income", Operator: "gt", Value: 1.0}, ErrInvalidRule},
+		{"unknown operator", Rule{Field: FieldAge, Operator: "like", Value: 1.0}, ErrInvalidRule},
+		{"between needs pair", Rule{Field: FieldAge, Operator: "between", Value: []any{1.0}}, ErrInvalidRule},
+		{"in needs list", Rule{Field: FieldCountry, Operator: "in", Value: "USA"}, ErrInvalidRule},
+		{"eq needs scalar", Rule{Field: FieldCountry, Operator: "eq", Value: []any{"USA"}}, ErrInvalidRule},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := RuleSet{Rules: []Rule{tt.rule}}.Validate()
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+type staticLoader []RuleSet
+
+func (l staticLoader) LoadActive(context.Context) ([]RuleSet, error) {
+	return l, nil
+}
+
+func TestEngineSelect(t *testing.T) {
+	bank, other := 1, 2
+	auto := "AUTO"
+
+	engine := NewEngine(staticLoader{
+		{ID: 1, Version: 3},
+		{ID: 2, Version: 1, CreditType: &auto},
+		{ID: 3, Version: 2, BankID: &bank},
+		{ID: 4, Version: 1, BankID: &bank, CreditType: &auto},
+		{ID: 5, Version: 1, BankID: &other, Rules: []Rule{{Field: "bogus"}}},
+	})
+
+	if err := engine.Reload(context.Background()); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		bankID     int
+		creditType string
+		wantID     int
+	}{
+		{"bank and type", bank, "AUTO", 4},
+		{"bank only", bank, "MORTGAGE", 3},
+		{"type only", 9, "AUTO", 2},
+		{"global", 9, "MORTGAGE", 1},
+		{"invalid set skipped", other, "MORTGAGE", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := engine.Select(tt.bankID, tt.creditType); got.ID != tt.wantID {
+				t.Errorf("Select() = rule set %d, want %d", got.ID, tt.wantID)
+			}
+		})
+	}
+
+	if got := NewEngine(staticLoader{}).Select(bank, "AUTO"); got.ID != DefaultRuleSet.ID {
+		t.Errorf("empty engine Select() = %d, want default", got.ID)
+	}
+}
@@ -0,0 +1,375 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	CreditEventsStream = "credit_events"
+
+	// DeadLetterSuffix is appended to the stream name to get the stream where
+	// messages go after exhausting their attempts
+	DeadLetterSuffix = ":dead"
+)
+
+// ErrUnhandled is returned by dispatch for event types without a handler
+var ErrUnhandled = errors.New("no handler for event type")
+
+// permanentError marks failures that retrying cannot fix, e.g. a payload that
+// does not decode. Such messages go to the dead-letter stream right away.
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Message is an event read from the stream
+type Message struct {
+	StreamID string
+	Event    Event
+	Payload  json.RawMessage
+}
+
+type handlerFunc func(ctx context.Context, msg Message) error
+
+type ConsumerOptions struct {
+	Stream      string
+	Group       string
+	Name        string
+	BatchSize   int64
+	Block       time.Duration
+	MaxAttempts int
+	// ClaimIdle is how long a message may stay pending with another consumer
+	// before it is claimed, e.g. because that consumer crashed
+	ClaimIdle time.Duration
+	Backoff   func(attempt int) time.Duration
+}
+
+func (o *ConsumerOptions) setDefaults() {
+	if o.Stream == "" {
+		o.Stream = CreditEventsStream
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = 10
+	}
+	if o.Block <= 0 {
+		o.Block = 5 * time.Second
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 5
+	}
+	if o.ClaimIdle <= 0 {
+		o.ClaimIdle = time.Minute
+	}
+	if o.Backoff == nil {
+		o.Backoff = func(attempt int) time.Duration {
+			return min(100*time.Millisecond<<(attempt-1), 10*time.Second)
+		}
+	}
+}
+
+// Consumer reads a stream as a member of a Redis consumer group and dispatches
+// every message to the handler registered for its event type. Messages are
+// acknowledged once handled or moved to the dead-letter stream.
+type Consumer struct {
+	client   *redis.Client
+	opts     ConsumerOptions
+	handlers map[string]handlerFunc
+}
+
+func NewConsumer(client *redis.Client, opts ConsumerOptions) *Consumer {
+	opts.setDefaults()
+
+	return &Consumer{
+		client:   client,
+		opts:     opts,
+		handlers: make(map[string]handlerFunc),
+	}
+}
+
+// Handle registers fn for an event type, decoding the payload into T
+func Handle[T any](c *Consumer, eventType string, fn func(ctx context.Context, event Event, payload T) error) {
+	c.handlers[eventType] = func(ctx context.Context, msg Message) error {
+		var payload T
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return permanentError{fmt.Errorf("decode %s payload: %w", eventType, err)}
+		}
+
+		event := msg.Event
+		event.Payload = payload
+
+		return fn(ctx, event, payload)
+	}
+}
+
+// Run consumes until ctx is done. The group is created if it does not exist.
+func (c *Consumer) Run(ctx context.Context) error {
+	err := c.client.XGroupCreateMkStream(ctx, c.opts.Stream, c.opts.Group, "0").Err()
+	if err != nil && !strings.Contains(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("create consumer group: %w", err)
+	}
+
+	claimTicker := time.NewTicker(c.opts.ClaimIdle)
+	defer claimTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case <-claimTicker.C:
+			if err := c.claim(ctx); err != nil && ctx.Err() == nil {
+				slog.Error("claiming pending events failed", "stream", c.opts.Stream, "err", err)
+			}
+
+		default:
+		}
+
+		streams, err := c.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    c.opts.Group,
+			Consumer: c.opts.Name,
+			Streams:  []string{c.opts.Stream, ">"},
+			Count:    c.opts.BatchSize,
+			Block:    c.opts.Block,
+		}).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			slog.Error("reading events failed", "stream", c.opts.Stream, "err", err)
+			sleep(ctx, time.Second)
+			continue
+		}
+
+		for _, stream := range streams {
+			for _, msg := range stream.Messages {
+				c.process(ctx, msg, 1)
+			}
+		}
+	}
+}
+
+// claim takes over messages left pending by other consumers for too long
+func (c *Consumer) claim(ctx context.Context) error {
+	start := "0-0"
+
+	for {
+		msgs, next, err := c.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   c.opts.Stream,
+			Group:    c.opts.Group,
+			Consumer: c.opts.Name,
+			MinIdle:  c.opts.ClaimIdle,
+			Start:    start,
+			Count:    c.opts.BatchSize,
+		}).Result()
+		if err != nil {
+			return err
+		}
+
+		deliveries := c.deliveryCounts(ctx, msgs)
+		for _, msg := range msgs {
+			c.process(ctx, msg, deliveries[msg.ID])
+		}
+
+		if next == "0-0" || len(msgs) == 0 {
+			return nil
+		}
+		start = next
+	}
+}
+
+// deliveryCounts returns how often each claimed message was delivered, the
+// claim included. A message whose count is unknown counts as delivered once.
+func (c *Consumer) deliveryCounts(ctx context.Context, msgs []redis.XMessage) map[string]int {
+	counts := make(map[string]int, len(msgs))
+	if len(msgs) == 0 {
+		return counts
+	}
+
+	pending, err := c.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream:   c.opts.Stream,
+		Group:    c.opts.Group,
+		Start:    msgs[0].ID,
+		End:      msgs[len(msgs)-1].ID,
+		Count:    int64(len(msgs)),
+		Consumer: c.opts.Name,
+	}).Result()
+	if err != nil {
+		slog.Warn("reading delivery counts failed", "stream", c.opts.Stream, "err", err)
+	}
+
+	for _, p := range pending {
+		counts[p.ID] = int(p.RetryCount)
+	}
+	for _, msg := range msgs {
+		if counts[msg.ID] < 1 {
+			counts[msg.ID] = 1
+		}
+	}
+
+	return counts
+}
+
+// process handles a message delivered for the given time, with retries, then
+// acknowledges it. Messages are left pending only when ctx is cancelled
+// mid-way.
+func (c *Consumer) process(ctx context.Context, raw redis.XMessage, delivery int) {
+	msg, err := decodeMessage(raw)
+
+	ctx, span := otel.Tracer(tracerName).Start(msg.Event.TraceContext(ctx), "consume "+msg.Event.Type,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "redis"),
+			attribute.String("messaging.destination.name", c.opts.Stream),
+			attribute.String("messaging.message.id", msg.Event.ID),
+		),
+	)
+	defer span.End()
+
+	attempt := delivery
+	if err == nil {
+		attempt, err = c.handle(ctx, msg, delivery)
+	}
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		if dlqErr := c.deadLetter(ctx, raw, attempt, err); dlqErr != nil {
+			slog.Error("dead-lettering event failed", "stream_id", raw.ID, "err", dlqErr)
+			return
+		}
+	}
+
+	if err := c.client.XAck(ctx, c.opts.Stream, c.opts.Group, raw.ID).Err(); err != nil {
+		slog.Error("acknowledging event failed", "stream_id", raw.ID, "err", err)
+	}
+}
+
+// handle dispatches msg until it succeeds, fails permanently or runs out of
+// attempts. Every earlier delivery counts as an attempt, so a message whose
+// handler crashed or hung the worker still ends up dead-lettered. It returns
+// the last attempt made.
+func (c *Consumer) handle(ctx context.Context, msg Message, delivery int) (int, error) {
+	attempt := delivery
+	if attempt > c.opts.MaxAttempts {
+		return attempt, permanentError{fmt.Errorf("delivered %d times, max attempts %d", delivery, c.opts.MaxAttempts)}
+	}
+
+	for {
+		err := c.dispatch(ctx, msg)
+		if err == nil || errors.Is(err, ErrUnhandled) {
+			return attempt, nil
+		}
+
+		var permanent permanentError
+		if errors.As(err, &permanent) || attempt >= c.opts.MaxAttempts {
+			return attempt, err
+		}
+
+		slog.Warn("event handler failed, retrying",
+			"stream_id", msg.StreamID, "type", msg.Event.Type, "attempt", attempt, "err", err)
+
+		if !sleep(ctx, c.opts.Backoff(attempt)) {
+			return attempt, ctx.Err()
+		}
+		attempt++
+	}
+}
+
+// dispatch runs the handler of the event type. A panic is recovered and
+// returned as the error of the attempt.
+func (c *Consumer) dispatch(ctx context.Context, msg Message) (err error) {
+	handler, ok := c.handlers[msg.Event.Type]
+	if !ok {
+		return ErrUnhandled
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("event handler panicked",
+				"stream_id", msg.StreamID, "type", msg.Event.Type, "panic", r, "stack", string(debug.Stack()))
+			err = fmt.Errorf("event handler panicked: %v", r)
+		}
+	}()
+
+	return handler(ctx, msg)
+}
+
+func (c *Consumer) deadLetter(ctx context.Context, raw redis.XMessage, attempts int, cause error) error {
+	values := make(map[string]any, len(raw.Values)+4)
+	for k, v := range raw.Values {
+		values[k] = v
+	}
+
+	values["original_id"] = raw.ID
+	values["error"] = cause.Error()
+	values["attempts"] = attempts
+	values["failed_at"] = time.Now().UTC().Format(time.RFC3339Nano)
+
+	slog.Error("event moved to dead-letter stream",
+		"stream_id", raw.ID, "attempts", attempts, "err", cause)
+
+	return c.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: c.opts.Stream + DeadLetterSuffix,
+		Values: values,
+	}).Err()
+}
+
+// decodeMessage reads the fields written by RedisPublisher
+func decodeMessage(raw redis.XMessage) (Message, error) {
+	data, _ := raw.Values["payload"].(string)
+
+	var env envelope
+	if err := json.Unmarshal([]byte(data), &env); err != nil {
+		return Message{}, permanentError{fmt.Errorf("decode event %s: %w", raw.ID, err)}
+	}
+
+	if t, ok := raw.Values["type"].(string); ok && env.Type == "" {
+		env.Type = t
+	}
+	if id, ok := raw.Values["event_id"].(string); ok && env.ID == "" {
+		env.ID = id
+	}
+
+	return Message{
+		StreamID: raw.ID,
+		Event:    env.Event,
+		Payload:  env.Payload,
+	}, nil
+}
+
+// sleep waits for d and reports false when ctx was cancelled first
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
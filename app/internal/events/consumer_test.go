@@ -0,0 +1,147 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func streamMessage(t *testing.T, event Event) redis.XMessage {
+	t.Helper()
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return redis.XMessage{
+		ID: "1-0",
+		Values: map[string]any{
+			"event_id": event.ID,
+			"type":     event.Type,
+			"payload":  string(data),
+		},
+	}
+}
+
+func TestConsumerDispatch(t *testing.T) {
+	c := NewConsumer(nil, ConsumerOptions{Group: "test"})
+
+	var got CreditCreatedEvent
+	Handle(c, "CreditCreated", func(ctx context.Context, event Event, payload CreditCreatedEvent) error {
+		if event.ID != "evt-1" {
+			t.Errorf("event.ID = %q, want evt-1", event.ID)
+		}
+		got = payload
+		return nil
+	})
+
+	want := CreditCreatedEvent{CreditID: 7, ClientID: 3, BankID: 2, CreditType: "AUTO"}
+
+	tests := []struct {
+		name    string
+		raw     redis.XMessage
+		wantErr error
+	}{
+		{
+			name: "typed payload",
+			raw:  streamMessage(t, Event{ID: "evt-1", Type: "CreditCreated", OccurredAt: time.Now(), Payload: want}),
+		},
+		{
+			name:    "unhandled type",
+			raw:     streamMessage(t, Event{ID: "evt-2", Type: "CreditClosed", Payload: want}),
+			wantErr: ErrUnhandled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := decodeMessage(tt.raw)
+			if err != nil {
+				t.Fatalf("decodeMessage() error = %v", err)
+			}
+
+			err = c.dispatch(context.Background(), msg)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("dispatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if got != want {
+		t.Errorf("payload = %+v, want %+v", got, want)
+	}
+}
+
+func TestConsumerPermanentErrors(t *testing.T) {
+	c := NewConsumer(nil, ConsumerOptions{Group: "test"})
+	Handle(c, "CreditCreated", func(context.Context, Event, CreditCreatedEvent) error {
+		return nil
+	})
+
+	_, err := decodeMessage(redis.XMessage{ID: "1-0", Values: map[string]any{"payload": "{"}})
+	if !errors.As(err, new(permanentError)) {
+		t.Errorf("decodeMessage() error = %v, want permanent", err)
+	}
+
+	msg, err := decodeMessage(streamMessage(t, Event{Type: "CreditCreated", Payload: "not an object"}))
+	if err != nil {
+		t.Fatalf("decodeMessage() error = %v", err)
+	}
+
+	if err := c.dispatch(context.Background(), msg); !errors.As(err, new(permanentError)) {
+		t.Errorf("dispatch() error = %v, want permanent", err)
+	}
+}
+
+func TestConsumerAttempts(t *testing.T) {
+	boom := errors.New("boom")
+
+	tests := []struct {
+		name        string
+		delivery    int
+		fail        func() error
+		wantCalls   int
+		wantAttempt int
+		wantErr     bool
+	}{
+		{"succeeds", 1, func() error { return nil }, 1, 1, false},
+		{"retries up to max", 1, func() error { return boom }, 3, 3, true},
+		{"panics count as failed attempts", 1, func() error { panic("handler bug") }, 3, 3, true},
+		{"redelivery continues the count", 2, func() error { return boom }, 2, 3, true},
+		{"redelivery past max is dead-lettered unrun", 4, func() error { return nil }, 0, 4, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConsumer(nil, ConsumerOptions{
+				Group:       "test",
+				MaxAttempts: 3,
+				Backoff:     func(int) time.Duration { return 0 },
+			})
+
+			calls := 0
+			Handle(c, "CreditCreated", func(context.Context, Event, CreditCreatedEvent) error {
+				calls++
+				return tt.fail()
+			})
+
+			msg, err := decodeMessage(streamMessage(t, Event{ID: "evt-1", Type: "CreditCreated", Payload: CreditCreatedEvent{CreditID: 1}}))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			attempt, err := c.handle(context.Background(), msg, tt.delivery)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("handle() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls || attempt != tt.wantAttempt {
+				t.Errorf("calls = %d, attempt = %d, want %d and %d", calls, attempt, tt.wantCalls, tt.wantAttempt)
+			}
+		})
+	}
+}
@@ -1,17 +1,143 @@
 package events
 
-import "time"
+import (
+    "time"
 
+    "api/internal/domain"
+)
+
+const (
+    TypeCreditCreated     = "CreditCreated"
+    TypeCreditUnderReview = "CreditUnderReview"
+    TypeCreditApproved    = "CreditApproved"
+    TypeCreditRejected    = "CreditRejected"
+    TypeCreditDisbursed   = "CreditDisbursed"
+    TypeCreditActivated   = "CreditActivated"
+    TypeCreditClosed      = "CreditClosed"
+    TypeCreditDefaulted   = "CreditDefaulted"
+)
+
+func init() {
+    Register[CreditCreatedEvent](TypeCreditCreated, 1)
+    Register[CreditApprovedEvent](TypeCreditApproved, 1)
+    Register[CreditRejectedEvent](TypeCreditRejected, 1)
+
+    for _, eventType := range []string{
+        TypeCreditUnderReview, TypeCreditDisbursed, TypeCreditActivated,
+        TypeCreditClosed, TypeCreditDefaulted,
+    } {
+        Register[CreditStatusChangedEvent](eventType, 1)
+    }
+}
+
+// CreditCreatedEvent carries the requested terms. Amount is the most the
+// client repays over the term: max_payment * term_months.
 type CreditCreatedEvent struct {
-    CreditID   int
-    ClientID   int
-    BankID     int
-    Amount     float64
-    CreditType string
+    CreditID   int     `json:"credit_id"`
+    ClientID   int     `json:"client_id"`
+    BankID     int     `json:"bank_id"`
+    Amount     float64 `json:"amount"`
+    MinPayment float64 `json:"min_payment"`
+    MaxPayment float64 `json:"max_payment"`
+    TermMonths int     `json:"term_months"`
+    CreditType string  `json:"credit_type"`
 }
 
 type CreditApprovedEvent struct {
-    CreditID   int
-    ClientID   int
-    ApprovedAt time.Time
-}
\ No newline at end of file
+    CreditID   int       `json:"credit_id"`
+    ClientID   int       `json:"client_id"`
+    BankID     int       `json:"bank_id"`
+    ApprovedAt time.Time `json:"approved_at"`
+}
+
+type CreditRejectedEvent struct {
+    CreditID   int       `json:"credit_id"`
+    ClientID   int       `json:"client_id"`
+    BankID     int       `json:"bank_id"`
+    Reason     string    `json:"reason"`
+    RejectedAt time.Time `json:"rejected_at"`
+}
+
+type CreditStatusChangedEvent struct {
+    CreditID  int       `json:"credit_id"`
+    ClientID  int       `json:"client_id"`
+    BankID    int       `json:"bank_id"`
+    From      string    `json:"from"`
+    To        string    `json:"to"`
+    ChangedAt time.Time `json:"changed_at"`
+}
+
+var creditTransitionTypes = map[string]string{
+    domain.CreditUnderReview: TypeCreditUnderReview,
+    domain.CreditApproved:    TypeCreditApproved,
+    domain.CreditRejected:    TypeCreditRejected,
+    domain.CreditDisbursed:   TypeCreditDisbursed,
+    domain.CreditActive:      TypeCreditActivated,
+    domain.CreditClosed:      TypeCreditClosed,
+    domain.CreditDefaulted:   TypeCreditDefaulted,
+}
+
+// CreditCreated builds the event emitted for a newly created credit
+func CreditCreated(credit domain.Credit) Event {
+    return Event{
+        Type:        TypeCreditCreated,
+        OccurredAt:  credit.CreatedAt,
+        AggregateID: credit.ID,
+        Payload: CreditCreatedEvent{
+            CreditID:   credit.ID,
+            ClientID:   credit.ClientID,
+            BankID:     credit.BankID,
+            Amount:     credit.MaxPayment * float64(credit.TermMonths),
+            MinPayment: credit.MinPayment,
+            MaxPayment: credit.MaxPayment,
+            TermMonths: credit.TermMonths,
+            CreditType: credit.CreditType,
+        },
+    }
+}
+
+// CreditTransitionEvent builds the single event emitted when a credit moves
+// from one status to its current one
+func CreditTransitionEvent(credit domain.Credit, from string, at time.Time) Event {
+    event := Event{
+        Type:        creditTransitionTypes[credit.Status],
+        OccurredAt:  at,
+        AggregateID: credit.ID,
+    }
+
+    switch credit.Status {
+        case domain.CreditApproved:
+            event.Payload = CreditApprovedEvent{
+                CreditID:   credit.ID,
+                ClientID:   credit.ClientID,
+                BankID:     credit.BankID,
+                ApprovedAt: at,
+            }
+
+        case domain.CreditRejected:
+            var reason string
+            if credit.StatusReason != nil {
+                reason = *credit.StatusReason
+            }
+
+            event.Payload = CreditRejectedEvent{
+                CreditID:   credit.ID,
+                ClientID:   credit.ClientID,
+                BankID:     credit.BankID,
+                Reason:     reason,
+                RejectedAt: at,
+            }
+
+        default:
+            event.Payload = CreditStatusChangedEvent{
+                CreditID:  credit.ID,
+                ClientID:  credit.ClientID,
+                BankID:    credit.BankID,
+                From:      from,
+                To:        credit.Status,
+                ChangedAt: at,
+            }
+    }
+
+    return event
+}
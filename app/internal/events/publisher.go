@@ -2,15 +2,73 @@ package events
 
 import(
     "context"
+    "encoding/json"
     "time"
+
+    "github.com/go-chi/chi/v5/middleware"
+    "go.opentelemetry.io/otel"
+    "go.opentelemetry.io/otel/propagation"
 )
 
+const tracerName = "api/internal/events"
+
 type EventPublisher interface {
     Publish(ctx context.Context, event Event) error
 }
 
+// Event is the versioned envelope published to the credit_events stream.
+// ID is a deduplication key: delivery is at-least-once, so consumers may see
+// the same ID twice. Version is the schema version of Payload. TraceParent is
+// the W3C trace context of the request that caused the event.
 type Event struct {
-    Type      string
-    Timestamp time.Time
-    Payload   any
+    ID            string    `json:"event_id"`
+    Type          string    `json:"type"`
+    Version       int       `json:"schema_version"`
+    OccurredAt    time.Time `json:"occurred_at"`
+    CorrelationID string    `json:"correlation_id,omitempty"`
+    TraceParent   string    `json:"traceparent,omitempty"`
+    AggregateID   int       `json:"aggregate_id"`
+    Payload       any       `json:"payload"`
+}
+
+// Prepare fills the envelope fields that come from the registry and the
+// request, then validates the payload against the schema of its type
+func Prepare(ctx context.Context, event *Event) error {
+    def, err := Lookup(event.Type)
+    if err != nil {
+        return err
+    }
+
+    if event.Version == 0 {
+        event.Version = def.Version
+    }
+    if event.OccurredAt.IsZero() {
+        event.OccurredAt = time.Now().UTC()
+    }
+    if event.CorrelationID == "" {
+        event.CorrelationID = middleware.GetReqID(ctx)
+    }
+    if event.TraceParent == "" {
+        carrier := propagation.MapCarrier{}
+        otel.GetTextMapPropagator().Inject(ctx, carrier)
+        event.TraceParent = carrier.Get("traceparent")
+    }
+
+    return def.Validate(event.Payload)
+}
+
+// TraceContext returns ctx continuing the trace the event was published in
+func (e Event) TraceContext(ctx context.Context) context.Context {
+    if e.TraceParent == "" {
+        return ctx
+    }
+
+    carrier := propagation.MapCarrier{"traceparent": e.TraceParent}
+    return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}
+
+// envelope is Event with the payload left undecoded
+type envelope struct {
+    Event
+    Payload json.RawMessage `json:"payload"`
 }
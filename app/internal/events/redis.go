@@ -1,26 +1,47 @@
 package events
 
 import (
-    "context"
-    "encoding/json"
-    "github.com/redis/go-redis/v9"
+	"context"
+	"encoding/json"
+
+	"github.com/redis/go-redis/v9"
+
+	"api/pkg/database"
 )
 
 type RedisPublisher struct {
-    client *redis.Client
+	client *redis.Client
 }
 
 func NewRedisPublisher(client *redis.Client) *RedisPublisher {
-    return &RedisPublisher{client: client}
+	return &RedisPublisher{client: client}
 }
 
+// Publish validates the event against its registered schema and appends the
+// envelope to the stream
 func (p *RedisPublisher) Publish(ctx context.Context, event Event) error {
-    data, _ := json.Marshal(event)
-    return p.client.XAdd(ctx, &redis.XAddArgs{
-        Stream: "credit_events",
-        Values: map[string]any{
-            "type":    event.Type,
-            "payload": data,
-        },
-    }).Err()
-}
\ No newline at end of file
+	if err := Prepare(ctx, &event); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return p.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: CreditEventsStream,
+		Values: map[string]any{
+			"event_id":       event.ID,
+			"type":           event.Type,
+			"schema_version": event.Version,
+			"payload":        data,
+		},
+	}).Err()
+}
+
+// Available reports whether the Redis circuit breaker is closed. While it is
+// open events wait in the outbox instead of failing one by one.
+func (p *RedisPublisher) Available() bool {
+	return database.RedisAvailable()
+}
@@ -0,0 +1,219 @@
+package events
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "reflect"
+    "sort"
+    "time"
+
+    "api/internal/openapi"
+)
+
+var (
+    ErrUnknownEventType = errors.New("unknown event type")
+    ErrInvalidPayload   = errors.New("invalid event payload")
+)
+
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// Definition describes the payload of one event type at one schema version
+type Definition struct {
+    Type    string
+    Version int
+
+    payload    reflect.Type
+    components openapi.Components
+    schema     *openapi.Schema
+}
+
+var definitions = map[string]Definition{}
+
+// Register adds the payload type T for an event type. A breaking payload
+// change registers the new type with the next version.
+func Register[T any](eventType string, version int) {
+    var zero T
+
+    def := Definition{
+        Type:       eventType,
+        Version:    version,
+        payload:    reflect.TypeOf(zero),
+        components: openapi.Components{Schemas: make(map[string]*openapi.Schema)},
+    }
+    def.schema = def.resolve(def.components.SchemaOf(zero))
+    // payload fields are a contract: unknown ones are rejected, not dropped
+    def.schema.AdditionalProperties = false
+
+    definitions[eventType] = def
+}
+
+func Lookup(eventType string) (Definition, error) {
+    def, ok := definitions[eventType]
+    if !ok {
+        return Definition{}, fmt.Errorf("%w: %q", ErrUnknownEventType, eventType)
+    }
+
+    return def, nil
+}
+
+// Definitions returns every registered event type ordered by name
+func Definitions() []Definition {
+    list := make([]Definition, 0, len(definitions))
+    for _, def := range definitions {
+        list = append(list, def)
+    }
+
+    sort.Slice(list, func(i, j int) bool { return list[i].Type < list[j].Type })
+
+    return list
+}
+
+// Validate checks the JSON form of payload against the registered schema
+func (d Definition) Validate(payload any) error {
+    data, err := json.Marshal(payload)
+    if err != nil {
+        return fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+    }
+
+    var value any
+    if err := json.Unmarshal(data, &value); err != nil {
+        return fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+    }
+
+    if err := d.validate(d.schema, value, "payload"); err != nil {
+        return fmt.Errorf("%w: %s: %v", ErrInvalidPayload, d.Type, err)
+    }
+
+    return nil
+}
+
+// JSONSchema is a standalone JSON Schema document of an event envelope
+type JSONSchema struct {
+    Dialect string `json:"$schema"`
+    ID      string `json:"$id"`
+    Title   string `json:"title"`
+    *openapi.Schema
+}
+
+// JSONSchema describes the full envelope with this definition's payload
+func (d Definition) JSONSchema() JSONSchema {
+    components := openapi.Components{Schemas: make(map[string]*openapi.Schema)}
+    base := d.resolveIn(components, components.SchemaOf(Event{}))
+
+    envelope := *base
+    envelope.Properties = make(map[string]*openapi.Schema, len(base.Properties))
+    for name, prop := range base.Properties {
+        envelope.Properties[name] = prop
+    }
+
+    envelope.Properties["type"] = &openapi.Schema{Type: "string", Enum: []string{d.Type}}
+    envelope.Properties["event_id"] = &openapi.Schema{Type: "string", Format: "uuid"}
+    envelope.Properties["schema_version"] = &openapi.Schema{
+        Type:        "integer",
+        Description: fmt.Sprintf("Schema version of the payload, %d for this document", d.Version),
+    }
+    envelope.Properties["payload"] = d.schema
+    envelope.AdditionalProperties = false
+
+    return JSONSchema{
+        Dialect: jsonSchemaDialect,
+        ID:      fmt.Sprintf("urn:credits:events:%s:v%d", d.Type, d.Version),
+        Title:   d.Type,
+        Schema:  &envelope,
+    }
+}
+
+func (d Definition) resolve(s *openapi.Schema) *openapi.Schema {
+    return d.resolveIn(d.components, s)
+}
+
+func (d Definition) resolveIn(components openapi.Components, s *openapi.Schema) *openapi.Schema {
+    if s != nil && s.Ref != "" {
+        name := s.Ref[len("#/components/schemas/"):]
+        return components.Schemas[name]
+    }
+
+    return s
+}
+
+func (d Definition) validate(s *openapi.Schema, value any, path string) error {
+    s = d.resolve(s)
+    if s == nil || s.Type == nil {
+        return nil
+    }
+
+    switch s.Type {
+    case "object":
+        obj, ok := value.(map[string]any)
+        if !ok {
+            return fmt.Errorf("%s: expected object", path)
+        }
+
+        for _, name := range s.Required {
+            if v, ok := obj[name]; !ok || v == nil {
+                return fmt.Errorf("%s.%s: required", path, name)
+            }
+        }
+
+        for name, v := range obj {
+            prop, ok := s.Properties[name]
+            if !ok {
+                if s.AdditionalProperties == false {
+                    return fmt.Errorf("%s.%s: unexpected field", path, name)
+                }
+                continue
+            }
+
+            if v == nil {
+                continue
+            }
+
+            if err := d.validate(prop, v, path+"."+name); err != nil {
+                return err
+            }
+        }
+
+    case "array":
+        items, ok := value.([]any)
+        if !ok {
+            return fmt.Errorf("%s: expected array", path)
+        }
+
+        for i, item := range items {
+            if err := d.validate(s.Items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+                return err
+            }
+        }
+
+    case "integer":
+        n, ok := value.(float64)
+        if !ok || n != float64(int64(n)) {
+            return fmt.Errorf("%s: expected integer", path)
+        }
+
+    case "number":
+        if _, ok := value.(float64); !ok {
+            return fmt.Errorf("%s: expected number", path)
+        }
+
+    case "boolean":
+        if _, ok := value.(bool); !ok {
+            return fmt.Errorf("%s: expected boolean", path)
+        }
+
+    case "string":
+        str, ok := value.(string)
+        if !ok {
+            return fmt.Errorf("%s: expected string", path)
+        }
+
+        if s.Format == "date-time" {
+            if _, err := time.Parse(time.RFC3339Nano, str); err != nil {
+                return fmt.Errorf("%s: expected date-time", path)
+            }
+        }
+    }
+
+    return nil
+}
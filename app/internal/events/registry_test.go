@@ -0,0 +1,153 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestDefinitionValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		eventType string
+		payload   any
+		wantErr   error
+	}{
+		{
+			name:      "typed payload",
+			eventType: TypeCreditCreated,
+			payload:   CreditCreatedEvent{CreditID: 1, ClientID: 2, BankID: 3, CreditType: "AUTO"},
+		},
+		{
+			name:      "raw payload",
+			eventType: TypeCreditRejected,
+			payload:   json.RawMessage(`{"credit_id":1,"client_id":2,"bank_id":3,"reason":"income","rejected_at":"2026-01-02T03:04:05Z"}`),
+		},
+		{
+			name:      "missing field",
+			eventType: TypeCreditRejected,
+			payload:   json.RawMessage(`{"credit_id":1,"client_id":2,"bank_id":3,"rejected_at":"2026-01-02T03:04:05Z"}`),
+			wantErr:   ErrInvalidPayload,
+		},
+		{
+			name:      "unexpected field",
+			eventType: TypeCreditClosed,
+			payload:   map[string]any{"credit_id": 1, "client_id": 2, "bank_id": 3, "from": "ACTIVE", "to": "CLOSED", "changed_at": time.Now(), "extra": 1},
+			wantErr:   ErrInvalidPayload,
+		},
+		{
+			name:      "wrong type",
+			eventType: TypeCreditApproved,
+			payload:   map[string]any{"credit_id": "1", "client_id": 2, "bank_id": 3, "approved_at": time.Now()},
+			wantErr:   ErrInvalidPayload,
+		},
+		{
+			name:      "bad date-time",
+			eventType: TypeCreditApproved,
+			payload:   map[string]any{"credit_id": 1, "client_id": 2, "bank_id": 3, "approved_at": "yesterday"},
+			wantErr:   ErrInvalidPayload,
+		},
+		{
+			name:      "wrong struct for type",
+			eventType: TypeCreditApproved,
+			payload:   CreditCreatedEvent{CreditID: 1},
+			wantErr:   ErrInvalidPayload,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def, err := Lookup(tt.eventType)
+			if err != nil {
+				t.Fatalf("Lookup() error = %v", err)
+			}
+
+			if err := def.Validate(tt.payload); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPrepare(t *testing.T) {
+	event := Event{Type: TypeCreditCreated, AggregateID: 1, Payload: CreditCreatedEvent{CreditID: 1}}
+	if err := Prepare(context.Background(), &event); err != nil {
+		t.Fatalf("Prepare() error = %v", err)
+	}
+
+	if event.Version != 1 || event.OccurredAt.IsZero() {
+		t.Errorf("Prepare() = %+v, want version and occurred_at set", event)
+	}
+
+	unknown := Event{Type: "CreditExploded"}
+	if err := Prepare(context.Background(), &unknown); !errors.Is(err, ErrUnknownEventType) {
+		t.Errorf("Prepare() error = %v, want %v", err, ErrUnknownEventType)
+	}
+}
+
+func TestPrepareTraceParent(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	provider := sdktrace.NewTracerProvider()
+
+	ctx, span := provider.Tracer("test").Start(context.Background(), "request")
+	defer span.End()
+
+	event := Event{Type: TypeCreditCreated, AggregateID: 1, Payload: CreditCreatedEvent{CreditID: 1}}
+	if err := Prepare(ctx, &event); err != nil {
+		t.Fatalf("Prepare() error = %v", err)
+	}
+
+	traceID := span.SpanContext().TraceID().String()
+	if !strings.Contains(event.TraceParent, traceID) {
+		t.Fatalf("TraceParent = %q, want trace %s", event.TraceParent, traceID)
+	}
+
+	// the consumer side continues the same trace
+	got := trace.SpanContextFromContext(event.TraceContext(context.Background()))
+	if got.TraceID() != span.SpanContext().TraceID() || got.SpanID() != span.SpanContext().SpanID() {
+		t.Errorf("TraceContext() = %v, want parent %v", got, span.SpanContext())
+	}
+
+	untraced := Event{Type: TypeCreditCreated, AggregateID: 1, Payload: CreditCreatedEvent{CreditID: 1}}
+	if err := Prepare(context.Background(), &untraced); err != nil {
+		t.Fatal(err)
+	}
+	if untraced.TraceParent != "" {
+		t.Errorf("TraceParent = %q outside a trace, want empty", untraced.TraceParent)
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	def, err := Lookup(TypeCreditCreated)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc := def.JSONSchema()
+
+	if doc.ID != "urn:credits:events:CreditCreated:v1" {
+		t.Errorf("$id = %s", doc.ID)
+	}
+
+	for _, name := range []string{"event_id", "type", "schema_version", "occurred_at", "aggregate_id", "payload"} {
+		if _, ok := doc.Properties[name]; !ok {
+			t.Errorf("envelope property %s missing", name)
+		}
+	}
+
+	if got := doc.Properties["type"].Enum; len(got) != 1 || got[0] != TypeCreditCreated {
+		t.Errorf("type enum = %v", got)
+	}
+
+	if _, ok := doc.Properties["payload"].Properties["amount"]; !ok {
+		t.Error("payload schema lacks amount")
+	}
+}
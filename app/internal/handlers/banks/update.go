@@ -24,5 +24,5 @@ func update(ctx context.Context, data map[string]any) (interface{}, error) {
         bank_type = &v
     }
 
-    return services.BankService.Update(ctx, id, name, bank_type)
+    return services.BankService.Update(ctx, id, handlers.IfMatch(ctx), name, bank_type)
 }
\ No newline at end of file
@@ -13,13 +13,11 @@ func init() {
 }
 
 func list(ctx context.Context, data map[string]any) (interface{}, error) {
-    page, pageSize := 1, 20
+    page, pageSize, cursor, opts := handlers.ListParams(data)
 
-    if v, ok := data["page"].(int); ok && v > 0 {
-        page = v
+    if cursor != nil {
+        return services.ClientService.ListByCursor(ctx, *cursor, opts)
     }
-    if v, ok := data["page_size"].(int); ok && v > 0 {
-        pageSize = v
-    }
-    return services.ClientService.List(ctx, page, pageSize)
+
+    return services.ClientService.List(ctx, page, pageSize, opts)
 }
\ No newline at end of file
@@ -30,5 +30,5 @@ func update(ctx context.Context, data map[string]any) (interface{}, error) {
         country = &v
     }
 
-    return services.ClientService.Update(ctx, id, fullName, email, birthDate, country)
+    return services.ClientService.Update(ctx, id, handlers.IfMatch(ctx), fullName, email, birthDate, country)
 }
\ No newline at end of file
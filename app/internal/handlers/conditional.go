@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+type ifMatchKey struct{}
+
+var errInvalidETag = errors.New("invalid If-Match header")
+
+// ETag formats the version of a resource as a strong entity tag
+func ETag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// parseIfMatch returns the version an If-Match header asks for, zero for "*"
+func parseIfMatch(header string) (int, error) {
+	header = strings.TrimSpace(header)
+	if header == "*" {
+		return 0, nil
+	}
+
+	// weak tags never match under If-Match
+	unquoted, err := strconv.Unquote(header)
+	if err != nil || strings.HasPrefix(header, "W/") {
+		return 0, errInvalidETag
+	}
+
+	version, err := strconv.Atoi(unquoted)
+	if err != nil || version < 1 {
+		return 0, errInvalidETag
+	}
+
+	return version, nil
+}
+
+// IfMatch returns the version the request is conditional on, zero when it is
+// unconditional. Only set for contracts marked Conditional.
+func IfMatch(ctx context.Context) int {
+	version, _ := ctx.Value(ifMatchKey{}).(int)
+	return version
+}
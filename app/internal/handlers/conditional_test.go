@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	tests := []struct {
+		header  string
+		want    int
+		wantErr error
+	}{
+		{`"3"`, 3, nil},
+		{` "12" `, 12, nil},
+		{`*`, 0, nil},
+		{`3`, 0, errInvalidETag},
+		{`W/"3"`, 0, errInvalidETag},
+		{`"0"`, 0, errInvalidETag},
+		{`"abc"`, 0, errInvalidETag},
+		{`"1", "2"`, 0, errInvalidETag},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			got, err := parseIfMatch(tt.header)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("parseIfMatch(%q) error = %v, want %v", tt.header, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseIfMatch(%q) = %d, want %d", tt.header, got, tt.want)
+			}
+		})
+	}
+
+	if version, _ := parseIfMatch(ETag(7)); version != 7 {
+		t.Errorf("ETag(7) does not round trip, got %d", version)
+	}
+}
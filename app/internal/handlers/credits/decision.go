@@ -0,0 +1,17 @@
+package credits
+
+import (
+    "context"
+
+	"api/internal/handlers"
+	"api/internal/contracts/credits"
+	"api/internal/services"
+)
+
+func init() {
+    handlers.Register(credits.Decision, decision)
+}
+
+func decision(ctx context.Context, data map[string]any) (interface{}, error) {
+    return services.CreditService.Decision(ctx, data["id"].(int))
+}
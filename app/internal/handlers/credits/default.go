@@ -0,0 +1,18 @@
+package credits
+
+import (
+    "context"
+
+	"api/internal/handlers"
+	"api/internal/contracts/credits"
+	"api/internal/domain"
+	"api/internal/services"
+)
+
+func init() {
+    handlers.Register(credits.MarkDefaulted, markDefaulted)
+}
+
+func markDefaulted(ctx context.Context, data map[string]any) (interface{}, error) {
+    return services.CreditService.Transition(ctx, data["id"].(int), domain.CreditDefaulted, nil)
+}
\ No newline at end of file
@@ -15,7 +15,7 @@ func init() {
 func delete(ctx context.Context, data map[string]any) (interface{}, error) {
     id, _ := data["id"].(int)
 
-    if err := services.CreditService.Delete(ctx, data["id"].(int)); err != nil {
+    if err := services.CreditService.Delete(ctx, data["id"].(int), handlers.IfMatch(ctx)); err != nil {
         return nil, err
     }
 
@@ -13,15 +13,11 @@ func init() {
 }
 
 func list(ctx context.Context, data map[string]any) (interface{}, error) {
-	page, _ := data["page"].(int)
-	pageSize, _ := data["page_size"].(int)
+    page, pageSize, cursor, opts := handlers.ListParams(data)
 
-	if page == 0 {
-		page = 1
-	}
-	if pageSize == 0 {
-		pageSize = 20
-	}
+    if cursor != nil {
+        return services.CreditService.ListByCursor(ctx, *cursor, opts)
+    }
 
-	return services.CreditService.List(ctx, page, pageSize)
+    return services.CreditService.List(ctx, page, pageSize, opts)
 }
\ No newline at end of file
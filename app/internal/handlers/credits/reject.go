@@ -0,0 +1,20 @@
+package credits
+
+import (
+    "context"
+
+	"api/internal/handlers"
+	"api/internal/contracts/credits"
+	"api/internal/domain"
+	"api/internal/services"
+)
+
+func init() {
+    handlers.Register(credits.Reject, reject)
+}
+
+func reject(ctx context.Context, data map[string]any) (interface{}, error) {
+    reason := data["reason"].(string)
+
+    return services.CreditService.Transition(ctx, data["id"].(int), domain.CreditRejected, &reason)
+}
\ No newline at end of file
@@ -17,7 +17,7 @@ func update(ctx context.Context, data map[string]any) (interface{}, error) {
 
 	var minPayment, maxPayment *float64
 	var termMonths *int
-	var creditType, status *string
+	var creditType *string
 
 	if v, ok := data["min_payment"].(float64); ok {
 		minPayment = &v
@@ -31,9 +31,6 @@ func update(ctx context.Context, data map[string]any) (interface{}, error) {
 	if v, ok := data["credit_type"].(string); ok {
 		creditType = &v
 	}
-	if v, ok := data["status"].(string); ok {
-		status = &v
-	}
 
-	return services.CreditService.Update(ctx, id, minPayment, maxPayment, termMonths, creditType, status)
+	return services.CreditService.Update(ctx, id, handlers.IfMatch(ctx), minPayment, maxPayment, termMonths, creditType)
 }
\ No newline at end of file
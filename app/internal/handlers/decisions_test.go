@@ -0,0 +1,238 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+
+	"api/internal/contracts"
+	"api/internal/domain"
+	"api/internal/handlers"
+	_ "api/internal/handlers/credits"
+	_ "api/internal/handlers/eligibility"
+	"api/internal/middleware"
+	"api/internal/repository"
+)
+
+// row scans its values into the destinations, which must have their types
+type row []any
+
+func (r row) Scan(dest ...any) error {
+	for i, d := range dest {
+		reflect.ValueOf(d).Elem().Set(reflect.ValueOf(r[i]))
+	}
+	return nil
+}
+
+type errRow struct{ err error }
+
+func (r errRow) Scan(dest ...any) error {
+	return r.err
+}
+
+// fakeDB answers the queries of the eligibility and decision endpoints from
+// memory, anything else panics on the embedded nil DB
+type fakeDB struct {
+	middleware.DB
+
+	mu        sync.Mutex
+	clients   map[int]domain.Client
+	banks     map[int]domain.Bank
+	decisions []row
+}
+
+func (db *fakeDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	switch {
+	case strings.Contains(sql, "FROM clients WHERE id"):
+		if c, ok := db.clients[args[0].(int)]; ok {
+			return row{c.ID, c.FullName, c.Email, c.BirthDate, c.Country, c.CreatedAt, c.Version}
+		}
+
+	case strings.Contains(sql, "FROM banks WHERE id"):
+		if b, ok := db.banks[args[0].(int)]; ok {
+			return row{b.ID, b.Name, b.Type, b.CreatedAt, b.Version}
+		}
+
+	case strings.HasPrefix(sql, "INSERT INTO eligibility_decisions"):
+		id := len(db.decisions) + 1
+		db.decisions = append(db.decisions, append(row{id}, args...))
+		return row{id}
+
+	case strings.Contains(sql, "FROM eligibility_decisions"):
+		for i := len(db.decisions) - 1; i >= 0; i-- {
+			if creditID := db.decisions[i][1].(*int); creditID != nil && *creditID == args[0].(int) {
+				return db.decisions[i]
+			}
+		}
+	}
+
+	return errRow{pgx.ErrNoRows}
+}
+
+// ids are unique across the tests, the entity caches outlive them
+func newFakeDB(clientID, bankID int, age int) *fakeDB {
+	birth := time.Now().UTC().AddDate(-age, 0, -1).Format(time.DateOnly)
+
+	return &fakeDB{
+		clients: map[int]domain.Client{clientID: {ID: clientID, FullName: "A", Email: "a@example.com", BirthDate: birth, Country: "France", Version: 1}},
+		banks:   map[int]domain.Bank{bankID: {ID: bankID, Name: "B", Type: "GOVERNMENT", Version: 1}},
+	}
+}
+
+func newRouter(db *fakeDB) http.Handler {
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			next.ServeHTTP(w, req.WithContext(middleware.WithDB(req.Context(), db)))
+		})
+	})
+	handlers.RegisterAll(r)
+
+	return r
+}
+
+func serve(h http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	return w
+}
+
+func application(clientID, bankID int) string {
+	body, _ := json.Marshal(map[string]any{
+		"client_id": clientID, "bank_id": bankID, "min_payment": 100, "max_payment": 500,
+		"term_months": 12, "credit_type": "AUTO",
+	})
+	return string(body)
+}
+
+func factor(t *testing.T, decision domain.Decision, field string) domain.Factor {
+	t.Helper()
+
+	for _, f := range decision.Factors {
+		if f.Field == field {
+			return f
+		}
+	}
+
+	t.Fatalf("no %s factor in %+v", field, decision.Factors)
+	return domain.Factor{}
+}
+
+func TestEligibilityCheckExplainsRejection(t *testing.T) {
+	db := newFakeDB(101, 201, 17)
+
+	w := serve(newRouter(db), http.MethodPost, "/eligibility/check", application(101, 201))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+
+	var decision domain.Decision
+	if err := json.NewDecoder(w.Body).Decode(&decision); err != nil {
+		t.Fatal(err)
+	}
+
+	if decision.Eligible || decision.Score >= decision.Threshold {
+		t.Errorf("decision = %+v, want rejected", decision)
+	}
+
+	// the rejected field is listed with the applicant's value and no points
+	age := factor(t, decision, "age")
+	if age.Value != 17.0 || age.Points != 0 || len(age.MatchedRules) != 0 {
+		t.Errorf("age factor = %+v, want value 17 with 0 points", age)
+	}
+
+	country := factor(t, decision, "country")
+	if country.Value != "France" || country.Points != 10 || len(country.MatchedRules) != 1 {
+		t.Errorf("country factor = %+v", country)
+	}
+
+	if len(db.decisions) != 0 {
+		t.Errorf("a dry run recorded %d decisions", len(db.decisions))
+	}
+}
+
+func TestCreateRecordsRejectedDecision(t *testing.T) {
+	db := newFakeDB(102, 202, 17)
+
+	w := serve(newRouter(db), http.MethodPost, "/credits", application(102, 202))
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+
+	var body contracts.NotEligibleResponse
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Code != "not_eligible" || factor(t, body.Decision, "age").Value != 17.0 {
+		t.Errorf("body = %+v, want the decision explaining the age", body)
+	}
+
+	if len(db.decisions) != 1 {
+		t.Fatalf("recorded %d decisions, want 1", len(db.decisions))
+	}
+
+	var stored []domain.Factor
+	if err := json.Unmarshal(db.decisions[0][9].([]byte), &stored); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(stored, body.Decision.Factors) {
+		t.Errorf("stored factors = %+v, answered %+v", stored, body.Decision.Factors)
+	}
+}
+
+func TestGetDecision(t *testing.T) {
+	db := newFakeDB(103, 203, 30)
+	creditID := 7
+
+	recorded := domain.Decision{
+		CreditID:  &creditID,
+		ClientID:  103,
+		BankID:    203,
+		Score:     100,
+		Threshold: 50,
+		Eligible:  true,
+		Factors: []domain.Factor{
+			{Field: "age", Value: 30.0, Points: 35, MatchedRules: []domain.MatchedRule{{Operator: "between", Value: []any{18.0, 70.0}, Points: 35}}},
+			{Field: "country", Value: "USA", Points: 0, MatchedRules: []domain.MatchedRule{}},
+		},
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	if err := repository.NewDecisionRepository(db).Create(context.Background(), &recorded); err != nil {
+		t.Fatal(err)
+	}
+
+	router := newRouter(db)
+
+	w := serve(router, http.MethodGet, "/credits/7/decision", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+
+	var decision domain.Decision
+	if err := json.NewDecoder(w.Body).Decode(&decision); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decision, recorded) {
+		t.Errorf("decision = %+v, want %+v", decision, recorded)
+	}
+
+	if w := serve(router, http.MethodGet, "/credits/8/decision", ""); w.Code != http.StatusNotFound {
+		t.Errorf("unknown credit status = %d, want 404", w.Code)
+	}
+}
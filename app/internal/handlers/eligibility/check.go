@@ -0,0 +1,24 @@
+package eligibility
+
+import (
+    "context"
+
+	"api/internal/handlers"
+	"api/internal/contracts/eligibility"
+	"api/internal/services"
+)
+
+func init() {
+    handlers.Register(eligibility.Check, check)
+}
+
+func check(ctx context.Context, data map[string]any) (interface{}, error) {
+    return services.CreditService.CheckEligibility(ctx,
+        data["client_id"].(int),
+        data["bank_id"].(int),
+        data["min_payment"].(float64),
+        data["max_payment"].(float64),
+        data["term_months"].(int),
+        data["credit_type"].(string),
+    )
+}
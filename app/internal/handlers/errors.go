@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"api/internal/contracts"
+	"api/internal/domain"
+)
+
+// publicError is the domain error err is answered with. Errors outside the
+// taxonomy become domain.ErrInternal, so their text never reaches callers.
+func publicError(err error) *domain.Error {
+	var domainErr *domain.Error
+	if errors.As(err, &domainErr) {
+		return domainErr
+	}
+
+	return domain.ErrInternal
+}
+
+// handleError answers with the status, code and message of err, the full
+// error including internal detail is only logged
+func handleError(w http.ResponseWriter, err error) {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		slog.Info("request failed", "status", httpErr.Status, "err", err)
+		writeError(w, httpErr.Status, httpErr.Message)
+		return
+	}
+
+	public := publicError(err)
+	if public.Status >= http.StatusInternalServerError {
+		slog.Error("handler error", "code", public.Code, "err", err)
+	} else {
+		slog.Info("request failed", "code", public.Code, "err", err)
+	}
+
+	var notEligible *domain.NotEligibleError
+	if errors.As(err, &notEligible) {
+		writeJSON(w, public.Status, contracts.NotEligibleResponse{
+			Error:    public.Message,
+			Code:     public.Code,
+			Decision: notEligible.Decision,
+		})
+		return
+	}
+
+	writeJSON(w, public.Status, map[string]string{"error": public.Message, "code": public.Code})
+}
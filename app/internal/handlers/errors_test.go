@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"api/internal/domain"
+	baseRepo "api/pkg/repository"
+)
+
+func TestHandleError(t *testing.T) {
+	foreignKey := &pgconn.PgError{
+		Code:           "23503",
+		Message:        `insert or update on table "credits" violates foreign key constraint "credits_client_id_fkey"`,
+		TableName:      "credits",
+		ConstraintName: "credits_client_id_fkey",
+	}
+
+	tests := []struct {
+		name        string
+		err         error
+		wantStatus  int
+		wantCode    string
+		wantMessage string
+	}{
+		{"not found", domain.ErrNotFound, http.StatusNotFound, "not_found", "resource not found"},
+		{"wrapped not found", fmt.Errorf("load credit: %w", domain.ErrNotFound), http.StatusNotFound, "not_found", "resource not found"},
+		{"explained", domain.ErrInvalidTransition.Explain("PENDING -> CLOSED"), http.StatusConflict, "invalid_transition", "invalid status transition: PENDING -> CLOSED"},
+		{"version conflict", domain.CheckVersion(domain.Credit{Version: 3}, 2), http.StatusPreconditionFailed, "version_conflict", "resource was modified concurrently: at version 3, expected 2"},
+		{"foreign key", baseRepo.NewBaseRepository(nil).HandleError(foreignKey), http.StatusUnprocessableEntity, "invalid_reference", "referenced resource does not exist: client_id"},
+		{"unique", baseRepo.NewBaseRepository(nil).HandleError(&pgconn.PgError{Code: "23505", Message: "duplicate key"}), http.StatusConflict, "already_exists", "already exists"},
+		{"not eligible", &domain.NotEligibleError{}, http.StatusUnprocessableEntity, "not_eligible", "client not eligible for credit"},
+		{"unknown", errors.New(`dial tcp 10.0.0.5:5432: connection refused`), http.StatusInternalServerError, "internal", "internal server error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handleError(w, tt.err)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+
+			var body struct {
+				Error string `json:"error"`
+				Code  string `json:"code"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+
+			if body.Code != tt.wantCode || body.Error != tt.wantMessage {
+				t.Errorf("body = %+v, want %s %q", body, tt.wantCode, tt.wantMessage)
+			}
+			if strings.Contains(body.Error, "constraint") || strings.Contains(body.Error, "tcp") {
+				t.Errorf("internal detail leaked: %q", body.Error)
+			}
+		})
+	}
+
+	// the sentinels still match
+	if err := baseRepo.NewBaseRepository(nil).HandleError(foreignKey); !errors.Is(err, domain.ErrForeignKey) || !errors.As(err, new(*pgconn.PgError)) {
+		t.Errorf("HandleError() = %v, want ErrForeignKey wrapping the pg error", err)
+	}
+}
@@ -4,6 +4,8 @@ import (
     "context"
 
 	"api/internal/contracts"
+	"api/internal/middleware"
+	"api/pkg/database"
 )
 
 func init() {
@@ -11,5 +13,13 @@ func init() {
 }
 
 func health(ctx context.Context, data map[string]any) (interface{}, error) {
-    return map[string]string{"status": "ok"}, nil
-}
\ No newline at end of file
+	db, ok := middleware.GetDB(ctx).(*database.Cluster)
+	if !ok {
+		return contracts.HealthResponse{Status: database.StatusOK}, nil
+	}
+
+	return contracts.HealthResponse{
+		Status:   db.Status(),
+		Replicas: db.Replicas(),
+	}, nil
+}
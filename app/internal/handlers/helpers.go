@@ -1,5 +1,7 @@
 package handlers
 
+import baseRepo "api/pkg/repository"
+
 type HTTPError struct {
 	Status  int    `json:"status"`
 	Message string `json:"message"`
@@ -14,4 +16,49 @@ func NewHTTPError(status int, message string) error {
 
 func (e *HTTPError) Error() string {
 	return e.Message
-}
\ No newline at end of file
+}
+
+// ListParams splits the validated query of a list endpoint into pagination
+// and the filter/sort options understood by the repositories. cursor is set
+// when keyset pagination was requested.
+func ListParams(data map[string]any) (page, pageSize int, cursor *baseRepo.CursorParams, opts baseRepo.ListOptions) {
+	page, pageSize = 1, 20
+	opts.Filters = make(map[string]any, len(data))
+
+	var (
+		token        string
+		keyset       bool
+		includeTotal bool
+	)
+
+	for key, value := range data {
+		switch key {
+		case "page":
+			if v, ok := value.(int); ok && v > 0 {
+				page = v
+			}
+		case "page_size":
+			if v, ok := value.(int); ok && v > 0 {
+				pageSize = v
+			}
+		case "sort":
+			opts.Sort, _ = value.(string)
+		case "paging":
+			keyset = value == "CURSOR"
+		case "cursor":
+			token, _ = value.(string)
+			keyset = true
+		case "include_total":
+			includeTotal, _ = value.(bool)
+		default:
+			opts.Filters[key] = value
+		}
+	}
+
+	if keyset {
+		params := baseRepo.NewCursorParams(token, pageSize, includeTotal)
+		cursor = &params
+	}
+
+	return page, pageSize, cursor, opts
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+
+	"api/internal/contracts"
+	"api/internal/openapi"
+)
+
+var APIInfo = openapi.Info{
+	Title:       "Credit Decision & Management Service",
+	Description: "Clients, banks and credit applications",
+	Version:     "1.0.0",
+}
+
+// all routes are registered from init functions, so by the time the first
+// request arrives the document can be built once and reused
+var spec = sync.OnceValue(func() *openapi.Document {
+	return OpenAPI(nil)
+})
+
+func init() {
+	Register(contracts.OpenAPI, openapiSpec)
+}
+
+// OpenAPI builds the OpenAPI document for every registered contract
+func OpenAPI(servers []openapi.Server) *openapi.Document {
+	return openapi.Build(APIInfo, servers, Contracts())
+}
+
+func openapiSpec(ctx context.Context, data map[string]any) (interface{}, error) {
+	return spec(), nil
+}
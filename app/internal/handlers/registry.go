@@ -3,20 +3,33 @@ package handlers
 import (
 	"context"
 	"encoding/json"
-	"log/slog"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"time"
+
 	"github.com/go-chi/chi/v5"
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 
 	"api/internal/contracts"
 	"api/internal/domain"
+	"api/internal/middleware"
+	"api/internal/tracing"
 )
 
 type HandlerFunc func(ctx context.Context, data map[string]any) (interface{}, error)
 
 type Route struct {
-	Method  string
-	Path    string
-	Handler http.HandlerFunc
+	Method   string
+	Path     string
+	Contract contracts.Contract
+	Handler  http.HandlerFunc
 }
 
 var routes []Route
@@ -39,24 +52,127 @@ func RegisterAll(r chi.Router) {
 }
 
 func Register(contract contracts.Contract, handler HandlerFunc) {
+	wrapped := wrapWithValidation(handler, contract)
+	if contract.Method == http.MethodPost {
+		wrapped = withIdempotency(wrapped, contract)
+	}
+	wrapped = withMetrics(wrapped, contract)
+	wrapped = withTracing(wrapped, contract)
+
 	routes = append(routes, Route{
-		Method:  contract.Method,
-		Path:    contract.URI,
-		Handler: wrapWithValidation(handler, contract),
+		Method:   contract.Method,
+		Path:     contract.URI,
+		Contract: contract,
+		Handler:  wrapped,
 	})
 }
 
+// Contracts returns the contracts of all registered routes
+func Contracts() []contracts.Contract {
+	list := make([]contracts.Contract, 0, len(routes))
+	for _, route := range routes {
+		list = append(list, route.Contract)
+	}
+
+	return list
+}
+
+// withTracing runs the request in a server span named after the contract,
+// continuing the trace of an incoming traceparent header
+func withTracing(next http.HandlerFunc, contract contracts.Contract) http.HandlerFunc {
+	name := contract.Method + " " + contract.URI
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.Tracer().Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", contract.Method),
+				attribute.String("http.route", contract.URI),
+				attribute.String("url.path", r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		if id := chimw.GetReqID(ctx); id != "" {
+			span.SetAttributes(attribute.String("http.request.id", id))
+		}
+
+		ww := chimw.NewWrapResponseWriter(w, r.ProtoMajor)
+		defer func() {
+			if p := recover(); p != nil {
+				span.SetAttributes(attribute.Int("http.response.status_code", http.StatusInternalServerError))
+				span.SetStatus(codes.Error, fmt.Sprint(p))
+				panic(p)
+			}
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			span.SetAttributes(attribute.Int("http.response.status_code", status))
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+		}()
+
+		next(ww, r.WithContext(ctx))
+	}
+}
+
+// withMetrics records count and latency of requests by contract route
+func withMetrics(next http.HandlerFunc, contract contracts.Contract) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		m := middleware.GetMetrics(r.Context())
+		if m == nil {
+			next(w, r)
+			return
+		}
+
+		start := time.Now()
+		ww := chimw.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		defer func() {
+			status := ww.Status()
+			// a panic is answered with 500 by the recoverer further out
+			if p := recover(); p != nil {
+				m.ObserveRequest(contract.URI, contract.Method, http.StatusInternalServerError, time.Since(start))
+				panic(p)
+			}
+
+			m.ObserveRequest(contract.URI, contract.Method, status, time.Since(start))
+		}()
+
+		next(ww, r)
+	}
+}
+
+// withIdempotency replays the stored response of a POST retried with the same
+// Idempotency-Key instead of running it again
+func withIdempotency(next http.HandlerFunc, contract contracts.Contract) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		guard := middleware.GetIdempotency(r.Context())
+		if guard == nil {
+			next(w, r)
+			return
+		}
+
+		guard.Handle(w, r, contract.URI, next)
+	}
+}
+
 func wrapWithValidation(fn HandlerFunc, contract contracts.Contract) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		validated := make(map[string]any)
+		var invalid contracts.ValidationError
 
 		// Validate URI params
 		for _, param := range contract.URIParams() {
 			value := chi.URLParam(r, param)
 			if value == "" {
-				writeError(w, http.StatusBadRequest, "missing "+param+" parameter")
-				return
+				invalid.Add(param, nil, contracts.ErrRequired)
+				continue
 			}
 
 			spec, ok := contract.Required[param]
@@ -68,25 +184,42 @@ func wrapWithValidation(fn HandlerFunc, contract contracts.Contract) http.Handle
 				return
 			}
 
-			if err := contracts.ValidateField(param, value, spec); err != nil {
-				writeError(w, http.StatusBadRequest, err.Error())
-				return
+			coerced, err := contracts.Coerce(value, spec)
+			if err == nil {
+				err = contracts.ValidateField(param, coerced, spec)
+			}
+			if err != nil {
+				invalid.Add(param, value, err)
+				continue
 			}
 
-			validated[param] = contracts.Normalize(value, spec)
+			validated[param] = contracts.Normalize(coerced, spec)
 		}
 
-		if r.ContentLength > 0 {
-			var input map[string]any
-			if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		// Validate query string
+		if len(r.URL.RawQuery) > 0 {
+			queryValidated, err := contracts.ValidateQuery(r.URL.Query(), contract)
+			if err != nil {
+				invalid.Add("", nil, err)
+			}
+
+			for k, v := range queryValidated {
+				validated[k] = v
+			}
+		}
+
+		// Validate body
+		body := contract.Body()
+		if len(body.Required) > 0 || len(body.Optional) > 0 {
+			input := map[string]any{}
+			if err := json.NewDecoder(r.Body).Decode(&input); err != nil && !errors.Is(err, io.EOF) {
 				writeError(w, http.StatusBadRequest, "invalid json format")
 				return
 			}
 
-			bodyValidated, err := contracts.Validate(input, contract)
+			bodyValidated, err := contracts.Validate(input, body)
 			if err != nil {
-				writeError(w, http.StatusBadRequest, err.Error())
-				return
+				invalid.Add("", nil, err)
 			}
 
 			for k, v := range bodyValidated {
@@ -94,6 +227,23 @@ func wrapWithValidation(fn HandlerFunc, contract contracts.Contract) http.Handle
 			}
 		}
 
+		// all failing fields of path, query and body are answered at once
+		if err := invalid.Err(); err != nil {
+			writeProblem(w, invalid.Problem(r.URL.Path))
+			return
+		}
+
+		if contract.Conditional {
+			if header := r.Header.Get("If-Match"); header != "" {
+				version, err := parseIfMatch(header)
+				if err != nil {
+					writeError(w, http.StatusPreconditionFailed, err.Error())
+					return
+				}
+				ctx = context.WithValue(ctx, ifMatchKey{}, version)
+			}
+		}
+
         w.Header().Set("Content-Type", "application/json")
 
 		data, err := fn(ctx, validated)
@@ -108,10 +258,17 @@ func wrapWithValidation(fn HandlerFunc, contract contracts.Contract) http.Handle
 		if contract.Method == "POST" && data != nil {
 			statusCode = http.StatusCreated
 		}
+		if contract.Status != 0 && data != nil {
+			statusCode = contract.Status
+		}
 		if data == nil {
 			statusCode = http.StatusNoContent
 		}
 
+		if versioned, ok := data.(domain.Versioned); ok && contract.Conditional {
+			w.Header().Set("ETag", ETag(versioned.CurrentVersion()))
+		}
+
 		w.WriteHeader(statusCode)
 		if data != nil {
 			json.NewEncoder(w).Encode(data)
@@ -119,33 +276,17 @@ func wrapWithValidation(fn HandlerFunc, contract contracts.Contract) http.Handle
 	}
 }
 
-func handleError(w http.ResponseWriter, err error) {
-    slog.Error("handler error", "err", err)
-
-    if httpErr, ok := err.(*HTTPError); ok {
-        writeError(w, httpErr.Status, httpErr.Message)
-        return
-    }
-
-    if err == domain.ErrNotFound {
-        writeError(w, http.StatusNotFound, "not found")
-        return
-    }
-
-    if err == domain.ErrInvalidInput {
-        writeError(w, http.StatusBadRequest, "invalid input")
-        return
-    }
-
-	if err == domain.ErrAlreadyExists {
-		writeError(w, http.StatusConflict, "already exists")
-			return
-		}
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
 
-	writeError(w, http.StatusInternalServerError, err.Error())
+// writeProblem answers with RFC 7807 problem details
+func writeProblem(w http.ResponseWriter, problem contracts.Problem) {
+	w.Header().Set("Content-Type", contracts.ProblemContentType)
+	writeJSON(w, problem.Status, problem)
 }
 
-func writeError(w http.ResponseWriter, status int, message string) {
+func writeJSON(w http.ResponseWriter, status int, body any) {
 	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(map[string]string{"error": message})
+	json.NewEncoder(w).Encode(body)
 }
\ No newline at end of file
@@ -0,0 +1,22 @@
+package webhooks
+
+import (
+    "context"
+
+	"api/internal/handlers"
+	"api/internal/contracts/webhooks"
+	"api/internal/services"
+)
+
+func init() {
+    handlers.Register(webhooks.Create, create)
+}
+
+func create(ctx context.Context, data map[string]any) (interface{}, error) {
+    return services.WebhookService.Create(ctx,
+        data["id"].(int),
+        data["url"].(string),
+        data["event_types"].([]string),
+        data["secret"].(string),
+    )
+}
@@ -0,0 +1,23 @@
+package webhooks
+
+import (
+    "context"
+
+	"api/internal/handlers"
+	"api/internal/contracts/webhooks"
+	"api/internal/services"
+)
+
+func init() {
+    handlers.Register(webhooks.Deliveries, deliveries)
+}
+
+func deliveries(ctx context.Context, data map[string]any) (interface{}, error) {
+    page, pageSize, cursor, _ := handlers.ListParams(data)
+
+    if cursor != nil {
+        return services.WebhookService.DeliveriesByCursor(ctx, data["id"].(int), *cursor)
+    }
+
+    return services.WebhookService.Deliveries(ctx, data["id"].(int), page, pageSize)
+}
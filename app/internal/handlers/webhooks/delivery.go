@@ -0,0 +1,17 @@
+package webhooks
+
+import (
+    "context"
+
+	"api/internal/handlers"
+	"api/internal/contracts/webhooks"
+	"api/internal/services"
+)
+
+func init() {
+    handlers.Register(webhooks.Delivery, delivery)
+}
+
+func delivery(ctx context.Context, data map[string]any) (interface{}, error) {
+    return services.WebhookService.Delivery(ctx, data["id"].(int))
+}
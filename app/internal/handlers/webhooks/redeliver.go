@@ -0,0 +1,17 @@
+package webhooks
+
+import (
+    "context"
+
+	"api/internal/handlers"
+	"api/internal/contracts/webhooks"
+	"api/internal/services"
+)
+
+func init() {
+    handlers.Register(webhooks.Redeliver, redeliver)
+}
+
+func redeliver(ctx context.Context, data map[string]any) (interface{}, error) {
+    return services.WebhookService.Redeliver(ctx, data["id"].(int))
+}
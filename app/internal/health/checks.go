@@ -0,0 +1,108 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+
+	"api/pkg/database"
+)
+
+// Postgres pings the primary through the pooler and degrades while reads
+// fall back to it because replicas are down or lagging
+func Postgres(db *database.Cluster) Check {
+	return Check{
+		Name:     "postgres",
+		Critical: true,
+		Run: func(ctx context.Context) (any, error) {
+			if err := db.Primary().Ping(ctx); err != nil {
+				return nil, err
+			}
+
+			replicas := db.Replicas()
+			if db.Status() != database.StatusOK {
+				return replicas, fmt.Errorf("%w: replicas down or lagging, reading from primary", ErrDegraded)
+			}
+
+			return replicas, nil
+		},
+	}
+}
+
+// Redis reports the circuit breaker in front of Redis. It only degrades:
+// with the breaker open the API serves from Postgres without caching.
+func Redis(client *redis.Client) Check {
+	return Check{
+		Name: "redis",
+		Run: func(ctx context.Context) (any, error) {
+			err := client.Ping(ctx).Err()
+			status := database.RedisStatus()
+
+			if status.State != database.BreakerClosed {
+				return status, fmt.Errorf("%w: circuit breaker %s, caching bypassed", ErrDegraded, status.State)
+			}
+
+			return status, err
+		},
+	}
+}
+
+// Stream reports length and consumer groups of an event stream. A missing
+// stream only degrades, it is created by the first published event.
+func Stream(client *redis.Client, stream string) Check {
+	return Check{
+		Name: "stream:" + stream,
+		Run: func(ctx context.Context) (any, error) {
+			info, err := client.XInfoStream(ctx, stream).Result()
+			if err != nil {
+				if errors.Is(err, redis.Nil) || strings.Contains(err.Error(), "no such key") {
+					return nil, fmt.Errorf("%w: stream %s does not exist", ErrDegraded, stream)
+				}
+				return nil, err
+			}
+
+			return map[string]any{
+				"length":        info.Length,
+				"groups":        info.Groups,
+				"last_entry_id": info.LastGeneratedID,
+			}, nil
+		},
+	}
+}
+
+// PoolSaturation degrades once the share of acquired connections reaches
+// threshold, requests then start queueing for connections
+func PoolSaturation(name string, pool *pgxpool.Pool, threshold float64) Check {
+	return Check{
+		Name: "pool:" + name,
+		Run: func(ctx context.Context) (any, error) {
+			stat := pool.Stat()
+
+			details := map[string]any{
+				"acquired":            stat.AcquiredConns(),
+				"idle":                stat.IdleConns(),
+				"total":               stat.TotalConns(),
+				"max":                 stat.MaxConns(),
+				"empty_acquire_count": stat.EmptyAcquireCount(),
+				"canceled_acquires":   stat.CanceledAcquireCount(),
+			}
+
+			if stat.MaxConns() == 0 {
+				return details, nil
+			}
+
+			usage := float64(stat.AcquiredConns()) / float64(stat.MaxConns())
+			details["usage"] = usage
+
+			if usage >= threshold {
+				return details, fmt.Errorf("%w: %d of %d connections in use", ErrDegraded, stat.AcquiredConns(), stat.MaxConns())
+			}
+
+			return details, nil
+		},
+	}
+}
@@ -0,0 +1,214 @@
+// Package health runs dependency checks for the liveness and readiness
+// probes.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK           = "ok"
+	StatusDegraded     = "degraded"
+	StatusDown         = "down"
+	StatusShuttingDown = "shutting_down"
+)
+
+const defaultTimeout = 2 * time.Second
+
+// ErrDegraded marks a check failure that still lets the service take
+// traffic, e.g. lagging replicas the primary stands in for
+var ErrDegraded = errors.New("degraded")
+
+// Check is one dependency probe. Run returns optional details for the
+// report. A failing critical check makes the service not ready, any other
+// failure, or one wrapping ErrDegraded, only degrades it.
+type Check struct {
+	Name     string
+	Critical bool
+	Timeout  time.Duration
+	Run      func(ctx context.Context) (any, error)
+}
+
+type CheckResult struct {
+	Status     string  `json:"status"`
+	Critical   bool    `json:"critical"`
+	Error      string  `json:"error,omitempty"`
+	Details    any     `json:"details,omitempty"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+type Report struct {
+	Status    string                 `json:"status"`
+	CheckedAt time.Time              `json:"checked_at"`
+	Checks    map[string]CheckResult `json:"checks"`
+}
+
+// Ready reports whether the service should receive traffic
+func (r Report) Ready() bool {
+	return r.Status == StatusOK || r.Status == StatusDegraded
+}
+
+// Registry holds the checks and caches their last report, so probes from
+// several load balancers do not multiply the load on dependencies
+type Registry struct {
+	ttl    time.Duration
+	checks []Check
+
+	mu     sync.Mutex
+	last   *Report
+	status map[string]string
+
+	shuttingDown atomic.Bool
+}
+
+func NewRegistry(ttl time.Duration) *Registry {
+	return &Registry{ttl: ttl, status: make(map[string]string)}
+}
+
+// Register adds a check, it is not safe to call once probes are served
+func (r *Registry) Register(check Check) {
+	if check.Timeout <= 0 {
+		check.Timeout = defaultTimeout
+	}
+
+	r.checks = append(r.checks, check)
+}
+
+// Shutdown fails readiness from now on, so load balancers stop routing
+// traffic before the server stops accepting it
+func (r *Registry) Shutdown() {
+	r.shuttingDown.Store(true)
+}
+
+// Report runs every check concurrently, each under its own timeout, unless
+// the cached report is younger than the ttl
+func (r *Registry) Report(ctx context.Context) Report {
+	if r.shuttingDown.Load() {
+		return Report{Status: StatusShuttingDown, CheckedAt: time.Now().UTC(), Checks: map[string]CheckResult{}}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.last != nil && time.Since(r.last.CheckedAt) < r.ttl {
+		return *r.last
+	}
+
+	report := Report{
+		Status:    StatusOK,
+		CheckedAt: time.Now().UTC(),
+		Checks:    make(map[string]CheckResult, len(r.checks)),
+	}
+
+	// the report is shared through the cache, a caller going away must not
+	// fail it for everyone
+	ctx = context.WithoutCancel(ctx)
+	results := make([]CheckResult, len(r.checks))
+
+	var wg sync.WaitGroup
+	for i, check := range r.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = run(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	for i, check := range r.checks {
+		result := results[i]
+		report.Checks[check.Name] = result
+		report.Status = worse(report.Status, result.Status)
+
+		if prev := r.status[check.Name]; prev != result.Status {
+			r.status[check.Name] = result.Status
+			if result.Status == StatusOK {
+				slog.Info("health check recovered", "check", check.Name)
+			} else {
+				slog.Warn("health check failing", "check", check.Name, "status", result.Status, "err", result.Error)
+			}
+		}
+	}
+
+	r.last = &report
+
+	return report
+}
+
+func run(ctx context.Context, check Check) (result CheckResult) {
+	ctx, cancel := context.WithTimeout(ctx, check.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	defer func() {
+		result.DurationMs = float64(time.Since(start).Microseconds()) / 1000
+		result.Critical = check.Critical
+
+		if p := recover(); p != nil {
+			result.Status = StatusDown
+			result.Error = fmt.Sprint(p)
+		}
+	}()
+
+	details, err := check.Run(ctx)
+
+	result = CheckResult{Status: StatusOK, Details: details}
+	if err != nil {
+		result.Error = err.Error()
+		result.Status = StatusDegraded
+		if check.Critical && !errors.Is(err, ErrDegraded) {
+			result.Status = StatusDown
+		}
+	}
+
+	return result
+}
+
+var severity = map[string]int{StatusOK: 0, StatusDegraded: 1, StatusDown: 2}
+
+func worse(a, b string) string {
+	if severity[b] > severity[a] {
+		return b
+	}
+
+	return a
+}
+
+// LiveHandler answers /livez: the process is up and serving, dependencies
+// are deliberately not checked so an outage does not get every instance
+// restarted
+func (r *Registry) LiveHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": StatusOK})
+	}
+}
+
+// ReadyHandler answers /readyz with the full report, 503 while a critical
+// check fails or the server is shutting down
+func (r *Registry) ReadyHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		report := r.Report(req.Context())
+
+		status := http.StatusOK
+		if !report.Ready() {
+			status = http.StatusServiceUnavailable
+		}
+
+		writeJSON(w, status, report)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
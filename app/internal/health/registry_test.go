@@ -0,0 +1,135 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func check(name string, critical bool, err error) Check {
+	return Check{
+		Name:     name,
+		Critical: critical,
+		Run: func(ctx context.Context) (any, error) {
+			return nil, err
+		},
+	}
+}
+
+func TestReportStatus(t *testing.T) {
+	failure := errors.New("connection refused")
+	degraded := fmt.Errorf("%w: replicas lagging", ErrDegraded)
+
+	tests := []struct {
+		name   string
+		checks []Check
+		want   string
+		ready  bool
+	}{
+		{"no checks", nil, StatusOK, true},
+		{"all passing", []Check{check("a", true, nil), check("b", false, nil)}, StatusOK, true},
+		{"optional failing", []Check{check("a", true, nil), check("b", false, failure)}, StatusDegraded, true},
+		{"critical degraded", []Check{check("a", true, degraded)}, StatusDegraded, true},
+		{"critical failing", []Check{check("a", true, failure), check("b", false, failure)}, StatusDown, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry(0)
+			for _, c := range tt.checks {
+				r.Register(c)
+			}
+
+			report := r.Report(context.Background())
+			if report.Status != tt.want {
+				t.Errorf("status = %q, want %q", report.Status, tt.want)
+			}
+			if report.Ready() != tt.ready {
+				t.Errorf("ready = %v, want %v", report.Ready(), tt.ready)
+			}
+			if len(report.Checks) != len(tt.checks) {
+				t.Errorf("got %d check results, want %d", len(report.Checks), len(tt.checks))
+			}
+		})
+	}
+}
+
+func TestReportTimeout(t *testing.T) {
+	r := NewRegistry(0)
+	r.Register(Check{
+		Name:     "slow",
+		Critical: true,
+		Timeout:  10 * time.Millisecond,
+		Run: func(ctx context.Context) (any, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	})
+
+	report := r.Report(context.Background())
+
+	if got := report.Checks["slow"]; got.Status != StatusDown || got.Error == "" {
+		t.Errorf("slow check = %+v, want down with error", got)
+	}
+}
+
+func TestReportPanic(t *testing.T) {
+	r := NewRegistry(0)
+	r.Register(Check{Name: "broken", Run: func(ctx context.Context) (any, error) {
+		panic("nil client")
+	}})
+
+	if got := r.Report(context.Background()).Checks["broken"]; got.Status != StatusDown {
+		t.Errorf("panicking check status = %q, want %q", got.Status, StatusDown)
+	}
+}
+
+func TestReportCache(t *testing.T) {
+	var runs atomic.Int32
+
+	r := NewRegistry(time.Minute)
+	r.Register(Check{Name: "counted", Run: func(ctx context.Context) (any, error) {
+		runs.Add(1)
+		return nil, nil
+	}})
+
+	for range 3 {
+		r.Report(context.Background())
+	}
+
+	if runs.Load() != 1 {
+		t.Errorf("check ran %d times within the ttl, want 1", runs.Load())
+	}
+}
+
+func TestReadyHandler(t *testing.T) {
+	r := NewRegistry(0)
+	r.Register(check("postgres", true, nil))
+
+	serve := func() (int, Report) {
+		rec := httptest.NewRecorder()
+		r.ReadyHandler()(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		var report Report
+		if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+			t.Fatalf("decode report: %v", err)
+		}
+		return rec.Code, report
+	}
+
+	if code, report := serve(); code != http.StatusOK || report.Checks["postgres"].Status != StatusOK {
+		t.Errorf("ready: code = %d, report = %+v", code, report)
+	}
+
+	r.Shutdown()
+
+	if code, report := serve(); code != http.StatusServiceUnavailable || report.Status != StatusShuttingDown {
+		t.Errorf("shutting down: code = %d, status = %q", code, report.Status)
+	}
+}
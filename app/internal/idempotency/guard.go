@@ -0,0 +1,259 @@
+// Package idempotency makes retried POST requests safe: the first response
+// to an Idempotency-Key is stored and replayed to every retry.
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"api/internal/domain"
+)
+
+const (
+	HeaderKey      = "Idempotency-Key"
+	HeaderReplayed = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+)
+
+var (
+	ErrKeyTooLong    = domain.NewError(http.StatusBadRequest, "idempotency_key_too_long", "Idempotency-Key must be at most 255 characters")
+	ErrKeyReused     = domain.NewError(http.StatusUnprocessableEntity, "idempotency_key_reused", "Idempotency-Key was already used with a different request")
+	ErrKeyInProgress = domain.NewError(http.StatusConflict, "idempotency_key_in_progress", "a request with this Idempotency-Key is still in progress")
+)
+
+// replayedHeaders are stored with the response and sent again on replay
+var replayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// Record is the stored state of a key. Status is zero while the first
+// request is still being handled.
+type Record struct {
+	Fingerprint string            `json:"fingerprint"`
+	Status      int               `json:"status,omitempty"`
+	Header      map[string]string `json:"header,omitempty"`
+	Body        []byte            `json:"body,omitempty"`
+}
+
+func (r Record) Done() bool {
+	return r.Status != 0
+}
+
+// Store keeps records by key
+type Store interface {
+	// Claim stores rec under key unless the key exists, in which case the
+	// existing record is returned with claimed false
+	Claim(ctx context.Context, key string, rec Record, ttl time.Duration) (existing Record, claimed bool, err error)
+	Complete(ctx context.Context, key string, rec Record, ttl time.Duration) error
+	Release(ctx context.Context, key string) error
+}
+
+type Options struct {
+	// TTL is how long responses are replayed
+	TTL time.Duration
+	// LockTTL bounds how long a key stays claimed by a request that never
+	// completes, e.g. after a crash
+	LockTTL time.Duration
+	// Wait is how long a concurrent duplicate waits for the first request
+	// before it gets 409
+	Wait time.Duration
+}
+
+type Guard struct {
+	store Store
+	opts  Options
+	poll  time.Duration
+}
+
+func NewGuard(store Store, opts Options) *Guard {
+	if opts.TTL <= 0 {
+		opts.TTL = 24 * time.Hour
+	}
+	if opts.LockTTL <= 0 {
+		opts.LockTTL = 30 * time.Second
+	}
+
+	return &Guard{store: store, opts: opts, poll: 50 * time.Millisecond}
+}
+
+// Handle runs next at most once per Idempotency-Key, caller and route.
+// Requests without the header pass through. A key reused with another body
+// is rejected with 422. Responses with 5xx are not stored, so the client
+// can retry them.
+func (g *Guard) Handle(w http.ResponseWriter, r *http.Request, route string, next http.HandlerFunc) {
+	key := r.Header.Get(HeaderKey)
+	if key == "" {
+		next(w, r)
+		return
+	}
+
+	if len(key) > maxKeyLength {
+		writeError(w, ErrKeyTooLong)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, domain.ErrInvalidInput.Explain("unreadable body"))
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	ctx := r.Context()
+	storeKey := "idempotency:" + caller(r) + ":" + r.Method + " " + route + ":" + key
+	fingerprint := Fingerprint(r.Method, r.URL.Path, body)
+
+	deadline := time.Now().Add(g.opts.Wait)
+
+	for {
+		existing, claimed, err := g.store.Claim(ctx, storeKey, Record{Fingerprint: fingerprint}, g.opts.LockTTL)
+		if err != nil {
+			// without the store the request still has to be served
+			slog.Warn("idempotency store unavailable, handling request unguarded", "err", err)
+			next(w, r)
+			return
+		}
+
+		if claimed {
+			g.run(w, r, storeKey, fingerprint, next)
+			return
+		}
+
+		if existing.Fingerprint != fingerprint {
+			writeError(w, ErrKeyReused)
+			return
+		}
+
+		if existing.Done() {
+			replay(w, existing)
+			return
+		}
+
+		if time.Now().After(deadline) {
+			w.Header().Set("Retry-After", "1")
+			writeError(w, ErrKeyInProgress)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(g.poll):
+		}
+	}
+}
+
+func (g *Guard) run(w http.ResponseWriter, r *http.Request, storeKey, fingerprint string, next http.HandlerFunc) {
+	rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+
+	// the outcome is stored even when the client is gone, its retry
+	// replays it
+	ctx := context.WithoutCancel(r.Context())
+
+	defer func() {
+		if p := recover(); p != nil {
+			g.release(ctx, storeKey)
+			panic(p)
+		}
+
+		if rec.status >= http.StatusInternalServerError {
+			g.release(ctx, storeKey)
+			return
+		}
+
+		stored := Record{
+			Fingerprint: fingerprint,
+			Status:      rec.status,
+			Header:      make(map[string]string),
+			Body:        rec.body.Bytes(),
+		}
+		for _, name := range replayedHeaders {
+			if value := w.Header().Get(name); value != "" {
+				stored.Header[name] = value
+			}
+		}
+
+		if err := g.store.Complete(ctx, storeKey, stored, g.opts.TTL); err != nil {
+			slog.Error("store idempotent response", "key", storeKey, "err", err)
+		}
+	}()
+
+	next(rec, r)
+}
+
+func (g *Guard) release(ctx context.Context, storeKey string) {
+	if err := g.store.Release(ctx, storeKey); err != nil {
+		slog.Error("release idempotency key", "key", storeKey, "err", err)
+	}
+}
+
+func replay(w http.ResponseWriter, rec Record) {
+	for name, value := range rec.Header {
+		w.Header().Set(name, value)
+	}
+	w.Header().Set(HeaderReplayed, "true")
+
+	w.WriteHeader(rec.Status)
+	w.Write(rec.Body)
+}
+
+// Fingerprint identifies a request by method, path and body. JSON bodies are
+// compared by content, whitespace and key order do not matter.
+func Fingerprint(method, path string, body []byte) string {
+	var parsed any
+	if err := json.Unmarshal(body, &parsed); err == nil {
+		if canonical, err := json.Marshal(parsed); err == nil {
+			body = canonical
+		}
+	}
+
+	h := sha256.New()
+	h.Write([]byte(method + " " + path + "\n"))
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// caller scopes keys to whoever sent the request, so two clients picking the
+// same key do not see each other's responses
+func caller(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		sum := sha256.Sum256([]byte(auth))
+		return hex.EncodeToString(sum[:8])
+	}
+
+	if client := r.Header.Get("X-Client-Id"); client != "" {
+		return client
+	}
+
+	return "anonymous"
+}
+
+// recorder passes the response through and keeps a copy of it
+type recorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func writeError(w http.ResponseWriter, err *domain.Error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(err.Status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Message, "code": err.Code})
+}
@@ -0,0 +1,263 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type memoryStore struct {
+	mu      sync.Mutex
+	records map[string]Record
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{records: make(map[string]Record)}
+}
+
+func (s *memoryStore) Claim(ctx context.Context, key string, rec Record, ttl time.Duration) (Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.records[key]; ok {
+		return existing, false, nil
+	}
+
+	s.records[key] = rec
+	return Record{}, true, nil
+}
+
+func (s *memoryStore) Complete(ctx context.Context, key string, rec Record, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[key] = rec
+	return nil
+}
+
+func (s *memoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+	return nil
+}
+
+// counter answers 201 with a body naming how often it ran
+func counter(calls *atomic.Int32, status int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(`{"call":` + strconv.Itoa(int(n)) + `}`))
+	}
+}
+
+func post(g *Guard, next http.HandlerFunc, key, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/credits", strings.NewReader(body))
+	if key != "" {
+		r.Header.Set(HeaderKey, key)
+	}
+
+	w := httptest.NewRecorder()
+	g.Handle(w, r, "/credits", next)
+
+	return w
+}
+
+func TestGuard(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		requests   []struct{ key, body string }
+		wantCodes  []int
+		wantCalls  int32
+		wantReplay bool
+	}{
+		{
+			name:   "without key every request runs",
+			status: http.StatusCreated,
+			requests: []struct{ key, body string }{
+				{"", `{"a":1}`}, {"", `{"a":1}`},
+			},
+			wantCodes: []int{http.StatusCreated, http.StatusCreated},
+			wantCalls: 2,
+		},
+		{
+			name:   "retry replays the first response",
+			status: http.StatusCreated,
+			requests: []struct{ key, body string }{
+				{"k1", `{"a":1,"b":2}`}, {"k1", `{ "b": 2, "a": 1 }`},
+			},
+			wantCodes:  []int{http.StatusCreated, http.StatusCreated},
+			wantCalls:  1,
+			wantReplay: true,
+		},
+		{
+			name:   "different body with the same key",
+			status: http.StatusCreated,
+			requests: []struct{ key, body string }{
+				{"k1", `{"a":1}`}, {"k1", `{"a":2}`},
+			},
+			wantCodes: []int{http.StatusCreated, http.StatusUnprocessableEntity},
+			wantCalls: 1,
+		},
+		{
+			name:   "client errors are replayed",
+			status: http.StatusBadRequest,
+			requests: []struct{ key, body string }{
+				{"k1", `{}`}, {"k1", `{}`},
+			},
+			wantCodes:  []int{http.StatusBadRequest, http.StatusBadRequest},
+			wantCalls:  1,
+			wantReplay: true,
+		},
+		{
+			name:   "server errors can be retried",
+			status: http.StatusInternalServerError,
+			requests: []struct{ key, body string }{
+				{"k1", `{}`}, {"k1", `{}`},
+			},
+			wantCodes: []int{http.StatusInternalServerError, http.StatusInternalServerError},
+			wantCalls: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGuard(newMemoryStore(), Options{})
+			var calls atomic.Int32
+			next := counter(&calls, tt.status)
+
+			var first, last *httptest.ResponseRecorder
+			for i, req := range tt.requests {
+				last = post(g, next, req.key, req.body)
+				if i == 0 {
+					first = last
+				}
+
+				if last.Code != tt.wantCodes[i] {
+					t.Errorf("request %d: code = %d, want %d", i, last.Code, tt.wantCodes[i])
+				}
+			}
+
+			if calls.Load() != tt.wantCalls {
+				t.Errorf("handler ran %d times, want %d", calls.Load(), tt.wantCalls)
+			}
+
+			replayed := last.Header().Get(HeaderReplayed) == "true"
+			if replayed != tt.wantReplay {
+				t.Errorf("replayed = %v, want %v", replayed, tt.wantReplay)
+			}
+			if replayed && last.Body.String() != first.Body.String() {
+				t.Errorf("replayed body = %q, want %q", last.Body.String(), first.Body.String())
+			}
+			if replayed && last.Header().Get("Content-Type") != "application/json" {
+				t.Errorf("replayed content type = %q", last.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestGuardConcurrentDuplicate(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	slow := func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusCreated)
+	}
+
+	t.Run("waits for the first request", func(t *testing.T) {
+		g := NewGuard(newMemoryStore(), Options{Wait: time.Second})
+		g.poll = time.Millisecond
+
+		done := make(chan *httptest.ResponseRecorder)
+		go func() { done <- post(g, slow, "k1", `{}`) }()
+		<-started
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			close(release)
+		}()
+
+		duplicate := post(g, slow, "k1", `{}`)
+		if duplicate.Code != http.StatusCreated || duplicate.Header().Get(HeaderReplayed) != "true" {
+			t.Errorf("duplicate: code = %d, replayed = %q", duplicate.Code, duplicate.Header().Get(HeaderReplayed))
+		}
+
+		if first := <-done; first.Code != http.StatusCreated {
+			t.Errorf("first: code = %d", first.Code)
+		}
+	})
+
+	t.Run("gives up with 409", func(t *testing.T) {
+		store := newMemoryStore()
+		store.records["idempotency:anonymous:POST /credits:k1"] = Record{Fingerprint: Fingerprint(http.MethodPost, "/credits", []byte(`{}`))}
+
+		g := NewGuard(store, Options{Wait: 5 * time.Millisecond})
+		g.poll = time.Millisecond
+
+		var calls atomic.Int32
+		w := post(g, counter(&calls, http.StatusCreated), "k1", `{}`)
+
+		if w.Code != http.StatusConflict || calls.Load() != 0 {
+			t.Errorf("code = %d, calls = %d, want 409 without running", w.Code, calls.Load())
+		}
+		if code := errorCode(t, w); code != ErrKeyInProgress.Code {
+			t.Errorf("error code = %q, want %q", code, ErrKeyInProgress.Code)
+		}
+	})
+}
+
+func errorCode(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+
+	var body struct{ Code string }
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+
+	return body.Code
+}
+
+func TestGuardErrorCodes(t *testing.T) {
+	tests := []struct {
+		name       string
+		firstBody  string
+		key        string
+		wantStatus int
+		wantCode   string
+	}{
+		{"key too long", "", strings.Repeat("k", maxKeyLength+1), http.StatusBadRequest, "idempotency_key_too_long"},
+		{"key reused", `{"a":1}`, "k1", http.StatusUnprocessableEntity, "idempotency_key_reused"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGuard(newMemoryStore(), Options{})
+			var calls atomic.Int32
+			next := counter(&calls, http.StatusCreated)
+
+			if tt.firstBody != "" {
+				post(g, next, tt.key, tt.firstBody)
+			}
+
+			w := post(g, next, tt.key, `{"a":2}`)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if code := errorCode(t, w); code != tt.wantCode {
+				t.Errorf("error code = %q, want %q", code, tt.wantCode)
+			}
+		})
+	}
+}
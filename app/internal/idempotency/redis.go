@@ -0,0 +1,66 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type RedisStore struct {
+	client *redis.Client
+}
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func (s *RedisStore) Claim(ctx context.Context, key string, rec Record, ttl time.Duration) (Record, bool, error) {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return Record{}, false, err
+	}
+
+	// the key can expire or be released between SETNX and GET, claim again
+	for range 3 {
+		claimed, err := s.client.SetNX(ctx, key, data, ttl).Result()
+		if err != nil {
+			return Record{}, false, err
+		}
+		if claimed {
+			return Record{}, true, nil
+		}
+
+		existing, err := s.client.Get(ctx, key).Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return Record{}, false, err
+		}
+
+		var stored Record
+		if err := json.Unmarshal(existing, &stored); err != nil {
+			return Record{}, false, err
+		}
+
+		return stored, false, nil
+	}
+
+	return Record{}, false, errors.New("idempotency key keeps disappearing")
+}
+
+func (s *RedisStore) Complete(ctx context.Context, key string, rec Record, ttl time.Duration) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	return s.client.Set(ctx, key, data, ttl).Err()
+}
+
+func (s *RedisStore) Release(ctx context.Context, key string) error {
+	return s.client.Del(ctx, key).Err()
+}
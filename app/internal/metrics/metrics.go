@@ -0,0 +1,149 @@
+// Package metrics exposes Prometheus metrics for HTTP, Postgres pools, the
+// entity caches, event publishing and eligibility scoring. Everything is
+// registered on the registry handed to New, so tests can use their own.
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"api/internal/eligibility"
+	"api/internal/events"
+	"api/pkg/database"
+)
+
+const namespace = "credits"
+
+type Metrics struct {
+	requests  *prometheus.CounterVec
+	latency   *prometheus.HistogramVec
+	cache     *prometheus.CounterVec
+	published *prometheus.CounterVec
+	score     *prometheus.HistogramVec
+
+	registerer prometheus.Registerer
+}
+
+// New creates the metrics and registers them on reg
+func New(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by contract route, method and status.",
+		}, []string{"route", "method", "status"}),
+
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by contract route, method and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+
+		cache: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_lookups_total",
+			Help:      "Entity cache lookups by cache and result (hit or miss).",
+		}, []string{"cache", "result"}),
+
+		published: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "events_published_total",
+			Help:      "Events handed to the stream by type and result (success or failure).",
+		}, []string{"type", "result"}),
+
+		score: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "eligibility_score",
+			Help:      "Eligibility scores by outcome.",
+			Buckets:   prometheus.LinearBuckets(0, 10, 11),
+		}, []string{"eligible"}),
+
+		registerer: reg,
+	}
+
+	reg.MustRegister(m.requests, m.latency, m.cache, m.published, m.score)
+
+	return m
+}
+
+// Handler serves the metrics of g
+func Handler(g prometheus.Gatherer) http.Handler {
+	return promhttp.HandlerFor(g, promhttp.HandlerOpts{})
+}
+
+// RegisterRuntime adds the Go runtime and process metrics
+func (m *Metrics) RegisterRuntime() {
+	m.registerer.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// RegisterPools reports the stats of pools by name and the lag of every
+// replica returned by replicas at every scrape
+func (m *Metrics) RegisterPools(pools map[string]*pgxpool.Pool, replicas func() []database.ReplicaStatus) {
+	m.registerer.MustRegister(newPoolCollector(pools, replicas))
+}
+
+// ObserveRequest records a request to a contract route, the route is the
+// contract URI so ids in paths do not multiply the series
+func (m *Metrics) ObserveRequest(route, method string, status int, took time.Duration) {
+	code := strconv.Itoa(status)
+
+	m.requests.WithLabelValues(route, method, code).Inc()
+	m.latency.WithLabelValues(route, method, code).Observe(took.Seconds())
+}
+
+// Hit and Miss make Metrics a repository.CacheStats
+func (m *Metrics) Hit(cache string) {
+	m.cache.WithLabelValues(cache, "hit").Inc()
+}
+
+func (m *Metrics) Miss(cache string) {
+	m.cache.WithLabelValues(cache, "miss").Inc()
+}
+
+// ObserveEligibility records the score of an evaluation
+func (m *Metrics) ObserveEligibility(result eligibility.Result) {
+	m.score.WithLabelValues(strconv.FormatBool(result.Eligible)).Observe(float64(result.Score))
+}
+
+// Publisher counts the outcome of every event published through next
+func (m *Metrics) Publisher(next events.EventPublisher) events.EventPublisher {
+	return &publisher{next: next, published: m.published}
+}
+
+type publisher struct {
+	next      events.EventPublisher
+	published *prometheus.CounterVec
+}
+
+func (p *publisher) Publish(ctx context.Context, event events.Event) error {
+	err := p.next.Publish(ctx, event)
+
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	p.published.WithLabelValues(event.Type, result).Inc()
+
+	return err
+}
+
+// Available passes on whether next can publish, the outbox relay pauses
+// while it cannot
+func (p *publisher) Available() bool {
+	if a, ok := p.next.(interface{ Available() bool }); ok {
+		return a.Available()
+	}
+
+	return true
+}
@@ -0,0 +1,145 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"api/internal/eligibility"
+	"api/internal/events"
+	"api/pkg/database"
+)
+
+type stubPublisher struct {
+	err       error
+	available bool
+}
+
+func (p stubPublisher) Publish(ctx context.Context, event events.Event) error {
+	return p.err
+}
+
+func (p stubPublisher) Available() bool {
+	return p.available
+}
+
+func TestObserveRequest(t *testing.T) {
+	m := New(prometheus.NewRegistry())
+
+	m.ObserveRequest("/credits/{id}", http.MethodGet, http.StatusOK, 10*time.Millisecond)
+	m.ObserveRequest("/credits/{id}", http.MethodGet, http.StatusOK, 20*time.Millisecond)
+	m.ObserveRequest("/credits/{id}", http.MethodGet, http.StatusNotFound, time.Millisecond)
+
+	tests := []struct {
+		status string
+		want   float64
+	}{
+		{"200", 2},
+		{"404", 1},
+		{"500", 0},
+	}
+
+	for _, tt := range tests {
+		got := testutil.ToFloat64(m.requests.WithLabelValues("/credits/{id}", http.MethodGet, tt.status))
+		if got != tt.want {
+			t.Errorf("requests with status %s = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+
+	if n := testutil.CollectAndCount(m.latency); n != 2 {
+		t.Errorf("latency series = %d, want one per status", n)
+	}
+}
+
+func TestCacheAndEligibility(t *testing.T) {
+	m := New(prometheus.NewRegistry())
+
+	m.Hit("credits")
+	m.Hit("credits")
+	m.Miss("credits")
+	m.Miss("banks")
+
+	if got := testutil.ToFloat64(m.cache.WithLabelValues("credits", "hit")); got != 2 {
+		t.Errorf("credits hits = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(m.cache.WithLabelValues("banks", "miss")); got != 1 {
+		t.Errorf("banks misses = %v, want 1", got)
+	}
+
+	m.ObserveEligibility(eligibility.Result{Score: 65, Eligible: true})
+	m.ObserveEligibility(eligibility.Result{Score: 20})
+
+	if n := testutil.CollectAndCount(m.score); n != 2 {
+		t.Errorf("score series = %d, want eligible and not eligible", n)
+	}
+}
+
+func TestPublisher(t *testing.T) {
+	m := New(prometheus.NewRegistry())
+	event := events.Event{Type: "credit.created"}
+
+	m.Publisher(stubPublisher{}).Publish(context.Background(), event)
+	m.Publisher(stubPublisher{err: errors.New("down")}).Publish(context.Background(), event)
+	m.Publisher(stubPublisher{err: errors.New("down")}).Publish(context.Background(), event)
+
+	if got := testutil.ToFloat64(m.published.WithLabelValues("credit.created", "success")); got != 1 {
+		t.Errorf("successes = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(m.published.WithLabelValues("credit.created", "failure")); got != 2 {
+		t.Errorf("failures = %v, want 2", got)
+	}
+
+	// the outbox relay still sees whether it can publish
+	wrapped := m.Publisher(stubPublisher{available: false})
+	if a, ok := wrapped.(interface{ Available() bool }); !ok || a.Available() {
+		t.Error("availability of the wrapped publisher is lost")
+	}
+}
+
+func TestHandler(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m := New(registry)
+	m.ObserveRequest("/banks", http.MethodPost, http.StatusCreated, time.Millisecond)
+
+	w := httptest.NewRecorder()
+	Handler(registry).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	want := `credits_http_requests_total{method="POST",route="/banks",status="201"} 1`
+	if !strings.Contains(w.Body.String(), want) {
+		t.Errorf("metrics output lacks %s", want)
+	}
+}
+
+func TestReplicaLag(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m := New(registry)
+	m.RegisterPools(nil, func() []database.ReplicaStatus {
+		return []database.ReplicaStatus{
+			{Host: "replica-1", Healthy: true, LagSeconds: 1.5, LagBytes: 4096},
+			{Host: "replica-2", Healthy: true},
+		}
+	})
+
+	want := `
+# HELP credits_replica_lag_bytes WAL the replica has yet to replay, at the last check.
+# TYPE credits_replica_lag_bytes gauge
+credits_replica_lag_bytes{replica="replica-1"} 4096
+credits_replica_lag_bytes{replica="replica-2"} 0
+# HELP credits_replica_lag_seconds Age of the last transaction the replica replayed, at the last check.
+# TYPE credits_replica_lag_seconds gauge
+credits_replica_lag_seconds{replica="replica-1"} 1.5
+credits_replica_lag_seconds{replica="replica-2"} 0
+`
+	err := testutil.GatherAndCompare(registry, strings.NewReader(want),
+		"credits_replica_lag_seconds", "credits_replica_lag_bytes")
+	if err != nil {
+		t.Error(err)
+	}
+}
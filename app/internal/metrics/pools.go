@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"api/pkg/database"
+)
+
+var (
+	poolAcquired = prometheus.NewDesc(namespace+"_db_pool_acquired_connections",
+		"Connections currently in use.", []string{"pool"}, nil)
+	poolIdle = prometheus.NewDesc(namespace+"_db_pool_idle_connections",
+		"Connections currently idle.", []string{"pool"}, nil)
+	poolTotal = prometheus.NewDesc(namespace+"_db_pool_total_connections",
+		"Connections open, in use, idle or being established.", []string{"pool"}, nil)
+	poolMax = prometheus.NewDesc(namespace+"_db_pool_max_connections",
+		"Maximum size of the pool.", []string{"pool"}, nil)
+	poolAcquires = prometheus.NewDesc(namespace+"_db_pool_acquires_total",
+		"Successful connection acquires.", []string{"pool"}, nil)
+	poolEmptyAcquires = prometheus.NewDesc(namespace+"_db_pool_empty_acquires_total",
+		"Acquires that had to wait for a connection.", []string{"pool"}, nil)
+	poolWait = prometheus.NewDesc(namespace+"_db_pool_acquire_wait_seconds_total",
+		"Time spent waiting for a connection by acquires that found the pool empty.", []string{"pool"}, nil)
+	replicaLag = prometheus.NewDesc(namespace+"_replica_lag_seconds",
+		"Age of the last transaction the replica replayed, at the last check.", []string{"replica"}, nil)
+	replicaLagBytes = prometheus.NewDesc(namespace+"_replica_lag_bytes",
+		"WAL the replica has yet to replay, at the last check.", []string{"replica"}, nil)
+)
+
+// poolCollector reads pgxpool stats and the replica lag at scrape time
+type poolCollector struct {
+	pools    map[string]*pgxpool.Pool
+	replicas func() []database.ReplicaStatus
+}
+
+func newPoolCollector(pools map[string]*pgxpool.Pool, replicas func() []database.ReplicaStatus) *poolCollector {
+	return &poolCollector{pools: pools, replicas: replicas}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		poolAcquired, poolIdle, poolTotal, poolMax, poolAcquires, poolEmptyAcquires, poolWait,
+		replicaLag, replicaLagBytes,
+	} {
+		ch <- desc
+	}
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	for name, pool := range c.pools {
+		stat := pool.Stat()
+
+		ch <- prometheus.MustNewConstMetric(poolAcquired, prometheus.GaugeValue, float64(stat.AcquiredConns()), name)
+		ch <- prometheus.MustNewConstMetric(poolIdle, prometheus.GaugeValue, float64(stat.IdleConns()), name)
+		ch <- prometheus.MustNewConstMetric(poolTotal, prometheus.GaugeValue, float64(stat.TotalConns()), name)
+		ch <- prometheus.MustNewConstMetric(poolMax, prometheus.GaugeValue, float64(stat.MaxConns()), name)
+		ch <- prometheus.MustNewConstMetric(poolAcquires, prometheus.CounterValue, float64(stat.AcquireCount()), name)
+		ch <- prometheus.MustNewConstMetric(poolEmptyAcquires, prometheus.CounterValue, float64(stat.EmptyAcquireCount()), name)
+		ch <- prometheus.MustNewConstMetric(poolWait, prometheus.CounterValue, stat.EmptyAcquireWaitTime().Seconds(), name)
+	}
+
+	for _, replica := range c.replicas() {
+		ch <- prometheus.MustNewConstMetric(replicaLag, prometheus.GaugeValue, replica.LagSeconds, replica.Host)
+		ch <- prometheus.MustNewConstMetric(replicaLagBytes, prometheus.GaugeValue, float64(replica.LagBytes), replica.Host)
+	}
+}
@@ -4,28 +4,44 @@ import (
 	"context"
 	"net/http"
 
-	"github.com/jackc/pgx/v5/pgxpool"
+	"api/pkg/database"
+	baseRepo "api/pkg/repository"
 )
 
 type contextKey string
 
 const dbKey contextKey = "db"
 
-func DBMiddleware(pool *pgxpool.Pool) func(http.Handler) http.Handler {
+// DB is what services query and open transactions on, the cluster outside
+// of tests
+type DB interface {
+	baseRepo.Querier
+	baseRepo.Beginner
+}
+
+// DBMiddleware injects the cluster and starts a read-your-writes session for
+// the request
+func DBMiddleware(cluster *database.Cluster) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			ctx := context.WithValue(r.Context(), dbKey, pool)
+			ctx := WithDB(r.Context(), cluster)
+			ctx = database.WithSession(ctx)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
-func GetDB(ctx context.Context) *pgxpool.Pool {
+// WithDB returns ctx carrying db for GetDB
+func WithDB(ctx context.Context, db DB) context.Context {
+	return context.WithValue(ctx, dbKey, db)
+}
+
+func GetDB(ctx context.Context) DB {
 	if v := ctx.Value(dbKey); v != nil {
-		if pool, ok := v.(*pgxpool.Pool); ok {
-			return pool
+		if db, ok := v.(DB); ok {
+			return db
 		}
 	}
 
 	return nil
-}
\ No newline at end of file
+}
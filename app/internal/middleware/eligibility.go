@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"api/internal/eligibility"
+)
+
+const eligibilityKey contextKey = "eligibility"
+
+func EligibilityMiddleware(engine *eligibility.Engine) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), eligibilityKey, engine)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func GetEligibility(ctx context.Context) *eligibility.Engine {
+	if v := ctx.Value(eligibilityKey); v != nil {
+		if engine, ok := v.(*eligibility.Engine); ok {
+			return engine
+		}
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"api/internal/idempotency"
+)
+
+const idempotencyKey contextKey = "idempotency"
+
+func IdempotencyMiddleware(guard *idempotency.Guard) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), idempotencyKey, guard)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func GetIdempotency(ctx context.Context) *idempotency.Guard {
+	if v := ctx.Value(idempotencyKey); v != nil {
+		if guard, ok := v.(*idempotency.Guard); ok {
+			return guard
+		}
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"api/internal/metrics"
+)
+
+const metricsKey contextKey = "metrics"
+
+func MetricsMiddleware(m *metrics.Metrics) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), metricsKey, m)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func GetMetrics(ctx context.Context) *metrics.Metrics {
+	if v := ctx.Value(metricsKey); v != nil {
+		if m, ok := v.(*metrics.Metrics); ok {
+			return m
+		}
+	}
+	return nil
+}
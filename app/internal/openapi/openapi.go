@@ -0,0 +1,298 @@
+package openapi
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"api/internal/contracts"
+)
+
+const Version = "3.1.0"
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type Server struct {
+	URL string `json:"url"`
+}
+
+type Document struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       Info                            `json:"info"`
+	Servers    []Server                        `json:"servers,omitempty"`
+	Paths      map[string]map[string]Operation `json:"paths"`
+	Components Components                      `json:"components"`
+}
+
+type Operation struct {
+	OperationID string              `json:"operationId"`
+	Tags        []string            `json:"tags,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Description string               `json:"description,omitempty"`
+	Required    bool                 `json:"required"`
+	Content     map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Headers     map[string]Header    `json:"headers,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// ErrorResponse mirrors the body written by the handler registry on failures.
+// Code is stable, e.g. not_found or invalid_reference.
+type ErrorResponse struct {
+	Error string `json:"error"`
+	Code  string `json:"code,omitempty"`
+}
+
+// Build generates an OpenAPI document describing the given contracts
+func Build(info Info, servers []Server, list []contracts.Contract) *Document {
+	doc := &Document{
+		OpenAPI:    Version,
+		Info:       info,
+		Servers:    servers,
+		Paths:      make(map[string]map[string]Operation),
+		Components: Components{Schemas: make(map[string]*Schema)},
+	}
+
+	errSchema := doc.Components.SchemaOf(ErrorResponse{})
+
+	sorted := make([]contracts.Contract, len(list))
+	copy(sorted, list)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].URI != sorted[j].URI {
+			return sorted[i].URI < sorted[j].URI
+		}
+		return sorted[i].Method < sorted[j].Method
+	})
+
+	for _, c := range sorted {
+		if c.Method == "" || c.URI == "" {
+			continue
+		}
+
+		item, ok := doc.Paths[c.URI]
+		if !ok {
+			item = make(map[string]Operation)
+			doc.Paths[c.URI] = item
+		}
+
+		item[strings.ToLower(c.Method)] = doc.operation(c, errSchema)
+	}
+
+	return doc
+}
+
+func (doc *Document) operation(c contracts.Contract, errSchema *Schema) Operation {
+	op := Operation{
+		OperationID: OperationID(c.Method, c.URI),
+		Responses:   make(map[string]Response),
+	}
+
+	if tag := tagOf(c.URI); tag != "" {
+		op.Tags = []string{tag}
+	}
+
+	for _, param := range c.URIParams() {
+		spec, ok := c.Required[param]
+		if !ok {
+			spec = c.Optional[param]
+		}
+
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:     param,
+			In:       "path",
+			Required: true,
+			Schema:   FieldSchema(spec),
+		})
+	}
+
+	for _, name := range sortedKeys(c.Query) {
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:     name,
+			In:       "query",
+			Required: false,
+			Schema:   FieldSchema(c.Query[name]),
+		})
+	}
+
+	if c.Method == http.MethodPost {
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:     "Idempotency-Key",
+			In:       "header",
+			Required: false,
+			Schema:   &Schema{Type: "string", MaxLength: intPtr(255), Description: "Retries with the same key replay the first response"},
+		})
+	}
+
+	if c.Conditional && c.Method != http.MethodGet {
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:     "If-Match",
+			In:       "header",
+			Required: false,
+			Schema:   &Schema{Type: "string", Description: "ETag of the version the change applies to"},
+		})
+	}
+
+	body := c.Body()
+	hasBody := hasRequestBody(c.Method) && len(body.Required)+len(body.Optional) > 0
+
+	if hasBody {
+		op.RequestBody = &RequestBody{
+			Description: rulesDescription(c.Rules),
+			Required:    len(body.Required) > 0,
+			Content: map[string]MediaType{
+				"application/json": {Schema: ObjectSchema(body.Required, body.Optional)},
+			},
+		}
+	}
+
+	status := http.StatusOK
+	if c.Method == http.MethodPost {
+		status = http.StatusCreated
+	}
+	if c.Status != 0 {
+		status = c.Status
+	}
+
+	success := Response{
+		Description: http.StatusText(status),
+		Content: map[string]MediaType{
+			"application/json": {Schema: doc.Components.SchemaOf(c.Response)},
+		},
+	}
+	if c.Conditional && c.Method != http.MethodDelete {
+		success.Headers = map[string]Header{
+			"ETag": {Description: "Version of the returned resource", Schema: &Schema{Type: "string"}},
+		}
+	}
+	op.Responses[statusKey(status)] = success
+
+	errResponse := func(status int) {
+		op.Responses[statusKey(status)] = Response{
+			Description: http.StatusText(status),
+			Content: map[string]MediaType{
+				"application/json": {Schema: errSchema},
+			},
+		}
+	}
+
+	if hasBody || len(op.Parameters) > 0 {
+		op.Responses[statusKey(http.StatusBadRequest)] = Response{
+			Description: "Validation failed, every invalid field is listed",
+			Content: map[string]MediaType{
+				contracts.ProblemContentType: {Schema: doc.Components.SchemaOf(contracts.Problem{})},
+			},
+		}
+	}
+	if len(c.URIParams()) > 0 {
+		errResponse(http.StatusNotFound)
+	}
+	if c.Conditional && c.Method != http.MethodGet {
+		errResponse(http.StatusPreconditionFailed)
+	}
+	if c.Method == http.MethodPost {
+		errResponse(http.StatusConflict)
+		errResponse(http.StatusUnprocessableEntity)
+	}
+	errResponse(http.StatusInternalServerError)
+
+	return op
+}
+
+// rulesDescription lists the cross-field rules of a request body
+func rulesDescription(rules []contracts.Rule) string {
+	if len(rules) == 0 {
+		return ""
+	}
+
+	lines := make([]string, len(rules))
+	for i, rule := range rules {
+		lines[i] = "- " + rule.String()
+	}
+
+	return "Rules checked once every field is valid:\n" + strings.Join(lines, "\n")
+}
+
+// OperationID derives a stable identifier from method and path:
+// GET /banks/{id} -> getBanksById
+func OperationID(method, uri string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+
+	for _, segment := range strings.Split(uri, "/") {
+		if segment == "" {
+			continue
+		}
+
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			b.WriteString("By")
+			segment = strings.Trim(segment, "{}")
+		}
+
+		for _, word := range strings.FieldsFunc(segment, isSeparator) {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+
+	return b.String()
+}
+
+func sortedKeys(fields map[string]contracts.FieldSpec) []string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func hasRequestBody(method string) bool {
+	return method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch
+}
+
+func tagOf(uri string) string {
+	for _, segment := range strings.Split(uri, "/") {
+		if segment != "" && !strings.HasPrefix(segment, "{") {
+			return strings.TrimSuffix(segment, ".json")
+		}
+	}
+
+	return ""
+}
+
+func isSeparator(r rune) bool {
+	return r == '_' || r == '-' || r == '.'
+}
+
+func statusKey(status int) string {
+	return strconv.Itoa(status)
+}
@@ -0,0 +1,146 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"api/internal/contracts"
+)
+
+type item struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Note      *string   `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type page[T any] struct {
+	Items []T   `json:"items"`
+	Total int64 `json:"total"`
+}
+
+func TestBuild(t *testing.T) {
+	list := []contracts.Contract{
+		{
+			Method: "PUT",
+			URI:    "/items/{id}",
+			Required: map[string]contracts.FieldSpec{
+				"id":   {Type: "int", Min: 1},
+				"name": {Type: "string", Min: 2, Max: 10},
+			},
+			Optional: map[string]contracts.FieldSpec{
+				"kind": {Type: "enum", Options: []string{"A", "B"}},
+			},
+			Response: item{},
+		},
+		{
+			Method: "GET",
+			URI:    "/items",
+			Query: map[string]contracts.FieldSpec{
+				"page": {Type: "int", Min: 1},
+			},
+			Response: page[item]{},
+		},
+	}
+
+	doc := Build(Info{Title: "test", Version: "1"}, nil, list)
+
+	if doc.OpenAPI != Version {
+		t.Fatalf("openapi = %q, want %q", doc.OpenAPI, Version)
+	}
+
+	put, ok := doc.Paths["/items/{id}"]["put"]
+	if !ok {
+		t.Fatal("missing PUT /items/{id}")
+	}
+
+	if put.OperationID != "putItemsById" {
+		t.Errorf("operationId = %q", put.OperationID)
+	}
+
+	if len(put.Parameters) != 1 || put.Parameters[0].Name != "id" || put.Parameters[0].In != "path" {
+		t.Fatalf("parameters = %+v", put.Parameters)
+	}
+
+	if got := *put.Parameters[0].Schema.Minimum; got != 1 {
+		t.Errorf("id minimum = %v, want 1", got)
+	}
+
+	body := put.RequestBody.Content["application/json"].Schema
+	if _, ok := body.Properties["id"]; ok {
+		t.Error("path param leaked into request body")
+	}
+
+	if !reflect.DeepEqual(body.Required, []string{"name"}) {
+		t.Errorf("body required = %v", body.Required)
+	}
+
+	if got := body.Properties["kind"].Enum; !reflect.DeepEqual(got, []string{"A", "B"}) {
+		t.Errorf("kind enum = %v", got)
+	}
+
+	if ref := put.Responses["200"].Content["application/json"].Schema.Ref; ref != "#/components/schemas/item" {
+		t.Errorf("response ref = %q", ref)
+	}
+
+	if _, ok := put.Responses["404"]; !ok {
+		t.Error("missing 404 response for path with params")
+	}
+
+	get := doc.Paths["/items"]["get"]
+	if get.RequestBody != nil {
+		t.Error("GET must not declare a request body")
+	}
+
+	if len(get.Parameters) != 1 || get.Parameters[0].In != "query" || get.Parameters[0].Required {
+		t.Errorf("query parameters = %+v", get.Parameters)
+	}
+
+	if _, ok := get.Responses["404"]; ok {
+		t.Error("unexpected 404 response for path without params")
+	}
+
+	if ref := get.Responses["200"].Content["application/json"].Schema.Ref; ref != "#/components/schemas/pageitem" {
+		t.Errorf("list response ref = %q", ref)
+	}
+
+	schema := doc.Components.Schemas["item"]
+	if !reflect.DeepEqual(schema.Required, []string{"created_at", "id", "name"}) {
+		t.Errorf("item required = %v", schema.Required)
+	}
+
+	if f := schema.Properties["created_at"].Format; f != "date-time" {
+		t.Errorf("created_at format = %q", f)
+	}
+
+	if _, err := json.Marshal(doc); err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+}
+
+func TestBuildConditional(t *testing.T) {
+	list := []contracts.Contract{
+		{Method: "GET", URI: "/items/{id}", Required: map[string]contracts.FieldSpec{"id": {Type: "int"}}, Conditional: true, Response: item{}},
+		{Method: "DELETE", URI: "/items/{id}", Required: map[string]contracts.FieldSpec{"id": {Type: "int"}}, Conditional: true},
+	}
+
+	doc := Build(Info{Title: "test", Version: "1"}, nil, list)
+
+	get := doc.Paths["/items/{id}"]["get"]
+	if _, ok := get.Responses["200"].Headers["ETag"]; !ok {
+		t.Error("conditional GET must declare the ETag header")
+	}
+	if _, ok := get.Responses["412"]; ok {
+		t.Error("GET cannot fail a precondition")
+	}
+
+	del := doc.Paths["/items/{id}"]["delete"]
+	if len(del.Parameters) != 2 || del.Parameters[1].Name != "If-Match" || del.Parameters[1].In != "header" {
+		t.Errorf("delete parameters = %+v", del.Parameters)
+	}
+	if _, ok := del.Responses["412"]; !ok {
+		t.Error("missing 412 response for conditional delete")
+	}
+}
@@ -0,0 +1,310 @@
+package openapi
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"api/internal/contracts"
+)
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 any                `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties any                `json:"additionalProperties,omitempty"`
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+var genericNameRe = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// FieldSchema converts a contract field spec into a JSON schema
+func FieldSchema(spec contracts.FieldSpec) *Schema {
+	switch spec.Type {
+	case "string":
+		s := &Schema{Type: "string"}
+		if spec.Min > 0 {
+			s.MinLength = intPtr(spec.Min)
+		}
+		if spec.Max > 0 {
+			s.MaxLength = intPtr(spec.Max)
+		}
+		return s
+
+	case "email":
+		return &Schema{Type: "string", Format: "email"}
+
+	case "uuid":
+		return &Schema{Type: "string", Format: "uuid"}
+
+	case "date":
+		return &Schema{Type: "string", Format: "date"}
+
+	case "int":
+		s := &Schema{Type: "integer", Format: "int64"}
+		if spec.Min > 0 {
+			s.Minimum = floatPtr(float64(spec.Min))
+		}
+		if spec.Max > 0 {
+			s.Maximum = floatPtr(float64(spec.Max))
+		}
+		return s
+
+	case "number":
+		s := &Schema{Type: "number", Format: "double"}
+		if spec.MinVal > 0 {
+			s.Minimum = floatPtr(spec.MinVal)
+		}
+		if spec.MaxVal > 0 {
+			s.Maximum = floatPtr(spec.MaxVal)
+		}
+		return s
+
+	case "enum":
+		return &Schema{Type: "string", Enum: spec.Options}
+
+	case "bool":
+		return &Schema{Type: "boolean"}
+
+	case "url":
+		return &Schema{Type: "string", Format: "uri"}
+
+	case "list":
+		s := &Schema{Type: "array", Items: &Schema{Type: "string", Enum: spec.Options}}
+		if spec.Min > 0 {
+			s.MinItems = intPtr(spec.Min)
+		}
+		if spec.Max > 0 {
+			s.MaxItems = intPtr(spec.Max)
+		}
+		return s
+
+	case "sort":
+		keys := strings.Join(spec.Options, "|")
+		return &Schema{
+			Type:        "string",
+			Pattern:     fmt.Sprintf("^-?(%s)(,-?(%s))*$", keys, keys),
+			Description: "Comma separated sort keys, prefix with - for descending order",
+		}
+
+	default:
+		return &Schema{}
+	}
+}
+
+// ObjectSchema builds an object schema from required and optional field specs
+func ObjectSchema(required, optional map[string]contracts.FieldSpec) *Schema {
+	s := &Schema{
+		Type:                 "object",
+		Properties:           make(map[string]*Schema, len(required)+len(optional)),
+		AdditionalProperties: false,
+	}
+
+	for name, spec := range required {
+		s.Properties[name] = FieldSchema(spec)
+		s.Required = append(s.Required, name)
+	}
+
+	for name, spec := range optional {
+		s.Properties[name] = FieldSchema(spec)
+	}
+
+	sort.Strings(s.Required)
+
+	return s
+}
+
+// Components collects named schemas of Go types referenced by responses
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// SchemaOf returns a schema for the Go type of v, registering named structs
+// as components and returning a $ref to them
+func (c *Components) SchemaOf(v any) *Schema {
+	if v == nil {
+		return &Schema{}
+	}
+
+	if alternatives, ok := v.(contracts.OneOf); ok {
+		s := &Schema{}
+		for _, alt := range alternatives {
+			s.OneOf = append(s.OneOf, c.SchemaOf(alt))
+		}
+		return s
+	}
+
+	return c.schemaOfType(reflect.TypeOf(v))
+}
+
+func (c *Components) schemaOfType(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+
+	case reflect.String:
+		return &Schema{Type: "string"}
+
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: c.schemaOfType(t.Elem())}
+
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: c.schemaOfType(t.Elem())}
+
+	case reflect.Interface:
+		return &Schema{}
+
+	case reflect.Struct:
+		name := TypeName(t)
+		if name == "" {
+			return c.structSchema(t)
+		}
+
+		ref := &Schema{Ref: "#/components/schemas/" + name}
+		if _, ok := c.Schemas[name]; ok {
+			return ref
+		}
+
+		// placeholder first, so self-referencing types terminate
+		c.Schemas[name] = &Schema{}
+		c.Schemas[name] = c.structSchema(t)
+
+		return ref
+
+	default:
+		return &Schema{}
+	}
+}
+
+func (c *Components) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name, omitEmpty, skip := jsonName(f)
+		if skip {
+			continue
+		}
+
+		if f.Anonymous && name == "" {
+			embedded := c.structSchema(indirect(f.Type))
+			for k, v := range embedded.Properties {
+				s.Properties[k] = v
+			}
+			s.Required = append(s.Required, embedded.Required...)
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		s.Properties[name] = c.schemaOfType(f.Type)
+
+		if !omitEmpty && f.Type.Kind() != reflect.Pointer {
+			s.Required = append(s.Required, name)
+		}
+	}
+
+	sort.Strings(s.Required)
+
+	return s
+}
+
+// TypeName returns the component name of a named type, flattening generic
+// instantiations: PaginatedResult[api/internal/domain.Bank] -> PaginatedResultBank
+func TypeName(t reflect.Type) string {
+	name := t.Name()
+	if name == "" {
+		return ""
+	}
+
+	open := strings.IndexByte(name, '[')
+	if open < 0 {
+		return name
+	}
+
+	base := name[:open]
+	args := strings.Split(strings.TrimSuffix(name[open+1:], "]"), ",")
+
+	for _, arg := range args {
+		if dot := strings.LastIndexByte(arg, '.'); dot >= 0 {
+			arg = arg[dot+1:]
+		}
+		base += genericNameRe.ReplaceAllString(arg, "")
+	}
+
+	return base
+}
+
+func jsonName(f reflect.StructField) (name string, omitEmpty, skip bool) {
+	tag, ok := f.Tag.Lookup("json")
+	if !ok {
+		return "", false, false
+	}
+
+	if tag == "-" {
+		return "", false, true
+	}
+
+	parts := strings.Split(tag, ",")
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" || opt == "omitzero" {
+			omitEmpty = true
+		}
+	}
+
+	return parts[0], omitEmpty, false
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return t
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
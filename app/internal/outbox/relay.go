@@ -0,0 +1,169 @@
+package outbox
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"api/internal/events"
+)
+
+// relayLockKey is the advisory lock that keeps a single relay draining the
+// outbox at a time across instances, which per-aggregate ordering relies on
+const relayLockKey = 0x6f7574626f78
+
+const (
+	baseBackoff = time.Second
+	maxBackoff  = 5 * time.Minute
+)
+
+// Relay drains the outbox into the event stream. Delivery is at-least-once:
+// an entry is marked published only after the publisher accepted it, so a
+// crash in between publishes it again with the same event id.
+type Relay struct {
+	db        *pgxpool.Pool
+	publisher events.EventPublisher
+	batchSize int
+}
+
+// availability is implemented by publishers that know their transport is
+// down, entries then stay in the outbox without using up attempts
+type availability interface {
+	Available() bool
+}
+
+func NewRelay(db *pgxpool.Pool, publisher events.EventPublisher, batchSize int) *Relay {
+	return &Relay{
+		db:        db,
+		publisher: publisher,
+		batchSize: batchSize,
+	}
+}
+
+// Run drains the outbox every interval until ctx is done
+func (r *Relay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			for {
+				n, err := r.Drain(ctx)
+				if err != nil {
+					slog.Error("outbox relay failed", "err", err)
+				}
+				// a full batch means there is probably more waiting
+				if err != nil || n < r.batchSize {
+					break
+				}
+			}
+		}
+	}
+}
+
+// Drain publishes one batch of pending entries and returns how many were
+// fetched. It does nothing when another relay holds the lock or the
+// publisher is unavailable.
+func (r *Relay) Drain(ctx context.Context) (int, error) {
+	var fetched int
+
+	if a, ok := r.publisher.(availability); ok && !a.Available() {
+		return 0, nil
+	}
+
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		var locked bool
+		if err := tx.QueryRow(ctx, "SELECT pg_try_advisory_xact_lock($1)", relayLockKey).Scan(&locked); err != nil {
+			return err
+		}
+		if !locked {
+			return nil
+		}
+
+		store := NewStore(tx)
+
+		entries, err := store.Pending(ctx, r.batchSize)
+		if err != nil {
+			return err
+		}
+
+		fetched = len(entries)
+		blocked := make(map[int]bool)
+
+		for _, entry := range entries {
+			// keep the order of an aggregate once one of its events failed
+			if blocked[entry.AggregateID] {
+				continue
+			}
+
+			if err := r.publish(ctx, entry); err != nil {
+				blocked[entry.AggregateID] = true
+
+				slog.Warn("outbox publish failed",
+					"event_id", entry.EventID, "type", entry.Type,
+					"attempts", entry.Attempts+1, "err", err)
+
+				if err := store.MarkFailed(ctx, entry.ID, err, Backoff(entry.Attempts+1)); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := store.MarkPublished(ctx, entry.ID); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return fetched, err
+}
+
+// publish sends one entry in a span that continues the trace of the request
+// that wrote it
+func (r *Relay) publish(ctx context.Context, entry Entry) error {
+	event := entry.Event()
+
+	ctx, span := otel.Tracer("api/internal/outbox").Start(event.TraceContext(ctx), "publish "+event.Type,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.message.id", event.ID),
+			attribute.Int("outbox.attempt", entry.Attempts+1),
+		),
+	)
+	defer span.End()
+
+	// consumers continue from this span rather than from the request
+	event.TraceParent = ""
+
+	err := r.publisher.Publish(ctx, event)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return err
+}
+
+// Backoff returns the delay before the given attempt, doubling from one
+// second up to five minutes
+func Backoff(attempt int) time.Duration {
+	delay := baseBackoff
+	for i := 1; i < attempt && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+
+	return min(delay, maxBackoff)
+}
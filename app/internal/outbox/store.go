@@ -0,0 +1,157 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"api/internal/events"
+	baseRepo "api/pkg/repository"
+)
+
+// Entry is an event waiting in the outbox table
+type Entry struct {
+	ID            int64
+	EventID       string
+	AggregateID   int
+	Type          string
+	Version       int
+	CorrelationID *string
+	TraceParent   *string
+	Payload       json.RawMessage
+	CreatedAt     time.Time
+	Attempts      int
+}
+
+// Event rebuilds the event that was added to the outbox
+func (e Entry) Event() events.Event {
+	event := events.Event{
+		ID:          e.EventID,
+		Type:        e.Type,
+		Version:     e.Version,
+		OccurredAt:  e.CreatedAt,
+		AggregateID: e.AggregateID,
+		Payload:     e.Payload,
+	}
+
+	if e.CorrelationID != nil {
+		event.CorrelationID = *e.CorrelationID
+	}
+	if e.TraceParent != nil {
+		event.TraceParent = *e.TraceParent
+	}
+
+	return event
+}
+
+type Store struct {
+	*baseRepo.BaseRepository
+}
+
+// NewStore takes the transaction that changes the aggregate, so the event is
+// committed or rolled back together with it
+func NewStore(db baseRepo.Querier) *Store {
+	return &Store{
+		BaseRepository: baseRepo.NewBaseRepository(db),
+	}
+}
+
+func scanEntry(row pgx.Row) (Entry, error) {
+	var entry Entry
+	err := row.Scan(&entry.ID, &entry.EventID, &entry.AggregateID, &entry.Type,
+		&entry.Version, &entry.CorrelationID, &entry.TraceParent, &entry.Payload,
+		&entry.CreatedAt, &entry.Attempts)
+
+	return entry, err
+}
+
+// Add validates the event and stores it for its aggregate. Events of the
+// same aggregate are published in the order they were added.
+func (s *Store) Add(ctx context.Context, event events.Event) error {
+	if err := events.Prepare(ctx, &event); err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(event.Payload)
+	if err != nil {
+		return err
+	}
+
+	var correlationID *string
+	if event.CorrelationID != "" {
+		correlationID = &event.CorrelationID
+	}
+
+	var traceParent *string
+	if event.TraceParent != "" {
+		traceParent = &event.TraceParent
+	}
+
+	query := `INSERT INTO outbox (aggregate_id, event_type, schema_version,
+							correlation_id, traceparent, payload, created_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err = s.DB().Exec(ctx, query, event.AggregateID, event.Type, event.Version,
+		correlationID, traceParent, payload, event.OccurredAt)
+
+	return s.HandleError(err)
+}
+
+// Pending returns unpublished entries in insertion order. Aggregates that have
+// an entry waiting for a retry are left out entirely, so a later event never
+// overtakes an earlier one of the same aggregate.
+func (s *Store) Pending(ctx context.Context, limit int) ([]Entry, error) {
+	query := `SELECT id, event_id, aggregate_id, event_type, schema_version,
+					 correlation_id, traceparent, payload, created_at, attempts
+			  FROM outbox
+			  WHERE published_at IS NULL
+				AND aggregate_id NOT IN (
+					SELECT aggregate_id FROM outbox
+					WHERE published_at IS NULL AND next_attempt_at > now()
+				)
+			  ORDER BY id
+			  LIMIT $1`
+
+	rows, err := s.DB().Query(ctx, query, limit)
+	if err != nil {
+		return nil, s.HandleError(err)
+	}
+
+	defer rows.Close()
+
+	entries := make([]Entry, 0, limit)
+	for rows.Next() {
+		entry, err := scanEntry(rows)
+		if err != nil {
+			return nil, s.HandleError(err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, s.HandleError(rows.Err())
+}
+
+func (s *Store) MarkPublished(ctx context.Context, id int64) error {
+	query := `UPDATE outbox SET published_at = now(), attempts = attempts + 1, last_error = NULL
+			  WHERE id = $1`
+
+	_, err := s.DB().Exec(ctx, query, id)
+
+	return s.HandleError(err)
+}
+
+// MarkFailed records a failed attempt and schedules the next one after delay
+func (s *Store) MarkFailed(ctx context.Context, id int64, cause error, delay time.Duration) error {
+	query := `UPDATE outbox
+			  SET attempts = attempts + 1,
+				  last_error = $2,
+				  next_attempt_at = now() + make_interval(secs => $3)
+			  WHERE id = $1`
+
+	_, err := s.DB().Exec(ctx, query, id, cause.Error(), delay.Seconds())
+
+	return s.HandleError(err)
+}
@@ -2,28 +2,52 @@ package repository
 
 import (
 	"context"
-	"encoding/json"
-	"strconv"
+	"errors"
+	"time"
 
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/redis/go-redis/v9"
 
 	"api/internal/domain"
 	baseRepo "api/pkg/repository"
 )
 
-const (
-	banksHash = "banks"
-	banksList = "banks:list"
-)
+// listIndexDepth is how many of the newest banks, clients and credits the
+// list indexes serve, deeper pages are read from Postgres
+const listIndexDepth = 1000
+
+// banks change rarely
+var bankCache = baseRepo.NewCache[domain.Bank](baseRepo.CacheOptions{
+	Name:        "banks",
+	TTL:         time.Hour,
+	NegativeTTL: 30 * time.Second,
+	LocalTTL:    5 * time.Second,
+})
+
+var bankIndex = baseRepo.NewListIndex(baseRepo.ListIndexOptions{
+	Key:   "banks:list",
+	Table: "banks",
+	Depth: listIndexDepth,
+}, bankCache, bankKey)
+
+var bankFilters = baseRepo.FilterSet{
+	Rules: map[string]baseRepo.FilterRule{
+		"type": {Column: "type", Op: baseRepo.OpEq},
+		"name": {Column: "name", Op: baseRepo.OpLike},
+	},
+	Sortable: map[string]string{
+		"id":         "id",
+		"name":       "name",
+		"created_at": "created_at",
+	},
+	DefaultSort: "created_at DESC, id DESC",
+}
 
 type BankRepository struct {
 	*baseRepo.BaseRepository
 	crud *baseRepo.CRUD[domain.Bank]
 }
 
-func NewBankRepository(db *pgxpool.Pool) *BankRepository {
+func NewBankRepository(db baseRepo.Querier) *BankRepository {
 	return &BankRepository{
 		BaseRepository: baseRepo.NewBaseRepository(db),
 		crud:           baseRepo.NewCRUD[domain.Bank](db, "banks"),
@@ -32,84 +56,103 @@ func NewBankRepository(db *pgxpool.Pool) *BankRepository {
 
 func scanBank(row pgx.Row) (domain.Bank, error) {
 	var bank domain.Bank
-	err := row.Scan(&bank.ID, &bank.Name, &bank.Type, &bank.CreatedAt)
+	err := row.Scan(&bank.ID, &bank.Name, &bank.Type, &bank.CreatedAt, &bank.Version)
 
 	return bank, err
 }
 
+func bankKey(bank domain.Bank) (time.Time, int) {
+	return bank.CreatedAt, bank.ID
+}
+
 func (r *BankRepository) Create(ctx context.Context, bank *domain.Bank) error {
-	query := `INSERT INTO banks (name, type, created_at) VALUES ($1, $2, $3) RETURNING id`
+	query := `INSERT INTO banks (name, type, created_at) VALUES ($1, $2, $3) RETURNING id, version`
 
-	err := r.DB().QueryRow(ctx, query, bank.Name, bank.Type, bank.CreatedAt).Scan(&bank.ID)
+	err := r.DB().QueryRow(ctx, query, bank.Name, bank.Type, bank.CreatedAt).Scan(&bank.ID, &bank.Version)
     if err != nil {
         return r.HandleError(err)
     }
 
-	// Cache
-	data, _ := json.Marshal(bank)
-	r.Redis().HSet(ctx, banksHash, strconv.Itoa(bank.ID), data)
-	r.Redis().ZAdd(ctx, banksList, redis.Z{Score: float64(bank.CreatedAt.Unix()), Member: strconv.Itoa(bank.ID)})
+	// Cache, the id may be remembered as missing
+	bankCache.Invalidate(ctx, bank.ID)
+	bankIndex.Add(ctx, *bank)
 
 	return nil
 }
 
 func (r *BankRepository) GetByID(ctx context.Context, id int) (*domain.Bank, error) {
-	// Try cache
-	data, err := r.Redis().HGet(ctx, banksHash, strconv.Itoa(id)).Bytes()
-	if err == nil {
-		var bank domain.Bank
-		if json.Unmarshal(data, &bank) == nil {
-			return &bank, nil
-		}
+	bank, err := bankCache.Get(ctx, id, func(ctx context.Context) (domain.Bank, error) {
+		return r.crud.GetByID(ctx, id, scanBank)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// DB
-	bank, err := r.crud.GetByID(ctx, id, scanBank)
+	return &bank, nil
+}
 
-	if err != nil {
-		return nil, err
+func (r *BankRepository) List(ctx context.Context, pagination baseRepo.PaginationParams, opts baseRepo.ListOptions) (baseRepo.PaginatedResult[domain.Bank], error) {
+	// the default listing, newest first, is served from the index
+	if len(opts.Filters) == 0 && opts.Sort == "" {
+		if page, ok := bankIndex.Page(ctx, pagination, r.getMany); ok {
+			return page, nil
+		}
 	}
 
-	// Cache
-	if data, err := json.Marshal(bank); err == nil {
-		r.Redis().HSet(ctx, banksHash, strconv.Itoa(id), data)
+	where, orderBy, args, err := bankFilters.Compile(opts, 0)
+	if err != nil {
+		return baseRepo.PaginatedResult[domain.Bank]{}, err
 	}
 
-	return &bank, nil
+	return r.crud.List(ctx, pagination, scanBank, where, orderBy, args...)
 }
 
-func (r *BankRepository) List(ctx context.Context, pagination baseRepo.PaginationParams) (baseRepo.PaginatedResult[domain.Bank], error) {
-	return r.crud.List(ctx, pagination, scanBank, "", "created_at DESC")
+func (r *BankRepository) getMany(ctx context.Context, ids []int) ([]domain.Bank, error) {
+	return r.crud.GetMany(ctx, ids, scanBank)
 }
 
-func (r *BankRepository) Update(ctx context.Context, bank *domain.Bank) error {
-	query := `UPDATE banks SET name = $1, type = $2 WHERE id = $3`
+func (r *BankRepository) ListKeyset(ctx context.Context, params baseRepo.CursorParams, opts baseRepo.ListOptions) (baseRepo.CursorResult[domain.Bank], error) {
+	if opts.Sort != "" {
+		return baseRepo.CursorResult[domain.Bank]{}, domain.ErrInvalidInput.Explain("sort is not supported with cursor pagination")
+	}
 
-	result, err := r.DB().Exec(ctx, query, bank.Name, bank.Type, bank.ID)
+	where, _, args, err := bankFilters.Compile(opts, 0)
 	if err != nil {
-		return r.HandleError(err)
+		return baseRepo.CursorResult[domain.Bank]{}, err
 	}
 
-	if result.RowsAffected() == 0 {
-		return domain.ErrNotFound
+	return r.crud.ListKeyset(ctx, params, scanBank, bankKey, where, args...)
+}
+
+// Update writes the bank if it is still at bank.Version and bumps the version
+func (r *BankRepository) Update(ctx context.Context, bank *domain.Bank) error {
+	query := `UPDATE banks SET name = $1, type = $2, version = version + 1
+			  WHERE id = $3 AND version = $4 RETURNING version`
+
+	err := r.DB().QueryRow(ctx, query, bank.Name, bank.Type, bank.ID, bank.Version).Scan(&bank.Version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return r.crud.Missed(ctx, bank.ID)
+	}
+	if err != nil {
+		return r.HandleError(err)
 	}
 
 	// Cache
-	data, _ := json.Marshal(bank)
-	r.Redis().HSet(ctx, banksHash, strconv.Itoa(bank.ID), data)
+	bankCache.Invalidate(ctx, bank.ID)
 
 	return nil
 }
 
-func (r *BankRepository) Delete(ctx context.Context, id int) error {
-	err := r.crud.Delete(ctx, id)
+// Delete removes the bank if it is still at version, zero skips the check
+func (r *BankRepository) Delete(ctx context.Context, id, version int) error {
+	err := r.crud.DeleteVersion(ctx, id, version)
 	if err != nil {
 		return err
 	}
 
 	// Cache
-	r.Redis().HDel(ctx, banksHash, strconv.Itoa(id))
-	r.Redis().ZRem(ctx, banksList, strconv.Itoa(id))
+	bankCache.Invalidate(ctx, id)
+	bankIndex.Remove(ctx, id)
 
 	return nil
 }
\ No newline at end of file
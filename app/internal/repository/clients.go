@@ -2,28 +2,48 @@ package repository
 
 import (
 	"context"
-	"encoding/json"
-	"strconv"
+	"errors"
+	"time"
 
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/redis/go-redis/v9"
 
 	"api/internal/domain"
 	baseRepo "api/pkg/repository"
 )
 
-const (
-	clientsHash = "clients"
-	clientsList = "clients:list"
-)
+var clientCache = baseRepo.NewCache[domain.Client](baseRepo.CacheOptions{
+	Name:        "clients",
+	TTL:         15 * time.Minute,
+	NegativeTTL: 30 * time.Second,
+	LocalTTL:    5 * time.Second,
+})
+
+var clientIndex = baseRepo.NewListIndex(baseRepo.ListIndexOptions{
+	Key:   "clients:list",
+	Table: "clients",
+	Depth: listIndexDepth,
+}, clientCache, clientKey)
+
+var clientFilters = baseRepo.FilterSet{
+	Rules: map[string]baseRepo.FilterRule{
+		"country":     {Column: "country", Op: baseRepo.OpEq},
+		"name_prefix": {Column: "full_name", Op: baseRepo.OpPrefix},
+	},
+	Sortable: map[string]string{
+		"id":         "id",
+		"full_name":  "full_name",
+		"country":    "country",
+		"created_at": "created_at",
+	},
+	DefaultSort: "created_at DESC, id DESC",
+}
 
 type ClientRepository struct {
 	*baseRepo.BaseRepository
 	crud *baseRepo.CRUD[domain.Client]
 }
 
-func NewClientRepository(db *pgxpool.Pool) *ClientRepository {
+func NewClientRepository(db baseRepo.Querier) *ClientRepository {
 	return &ClientRepository{
 		BaseRepository: baseRepo.NewBaseRepository(db),
 		crud:           baseRepo.NewCRUD[domain.Client](db, "clients"),
@@ -34,89 +54,109 @@ func scanClient(row pgx.Row) (domain.Client, error) {
 	var client domain.Client
 
 	err := row.Scan(&client.ID, &client.FullName, &client.Email,
-		&client.BirthDate, &client.Country, &client.CreatedAt)
+		&client.BirthDate, &client.Country, &client.CreatedAt, &client.Version)
 
 	return client, err
 }
 
+func clientKey(client domain.Client) (time.Time, int) {
+	return client.CreatedAt, client.ID
+}
+
 func (r *ClientRepository) Create(ctx context.Context, client *domain.Client) error {
 	query := `INSERT INTO clients (full_name, email, birth_date, country, created_at)
-			  VALUES ($1, $2, $3, $4, $5) RETURNING id`
+			  VALUES ($1, $2, $3, $4, $5) RETURNING id, version`
 
 	err := r.DB().QueryRow(ctx, query, client.FullName, client.Email,
-		client.BirthDate, client.Country, client.CreatedAt).Scan(&client.ID)
+		client.BirthDate, client.Country, client.CreatedAt).Scan(&client.ID, &client.Version)
 	if err != nil {
         return r.HandleError(err)
     }
 
-	// Cache
-	data, _ := json.Marshal(client)
-	r.Redis().HSet(ctx, clientsHash, strconv.Itoa(client.ID), data)
-	r.Redis().ZAdd(ctx, clientsList, redis.Z{Score: float64(client.CreatedAt.Unix()), Member: strconv.Itoa(client.ID)})
+	// Cache, the id may be remembered as missing
+	clientCache.Invalidate(ctx, client.ID)
+	clientIndex.Add(ctx, *client)
 
 	return nil
 }
 
 func (r *ClientRepository) GetByID(ctx context.Context, id int) (*domain.Client, error) {
-	// Try cache
-	data, err := r.Redis().HGet(ctx, clientsHash, strconv.Itoa(id)).Bytes()
-	if err == nil {
-		var client domain.Client
-		if json.Unmarshal(data, &client) == nil {
-			return &client, nil
-		}
+	client, err := clientCache.Get(ctx, id, func(ctx context.Context) (domain.Client, error) {
+		return r.crud.GetByID(ctx, id, scanClient)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// DB
-	client, err := r.crud.GetByID(ctx, id, scanClient)
+	return &client, nil
+}
 
-	if err != nil {
-		return nil, err
+func (r *ClientRepository) List(ctx context.Context, pagination baseRepo.PaginationParams, opts baseRepo.ListOptions) (baseRepo.PaginatedResult[domain.Client], error) {
+	// the default listing, newest first, is served from the index
+	if len(opts.Filters) == 0 && opts.Sort == "" {
+		if page, ok := clientIndex.Page(ctx, pagination, r.getMany); ok {
+			return page, nil
+		}
 	}
 
-	// Cache
-	if data, err := json.Marshal(client); err == nil {
-		r.Redis().HSet(ctx, clientsHash, strconv.Itoa(id), data)
+	where, orderBy, args, err := clientFilters.Compile(opts, 0)
+	if err != nil {
+		return baseRepo.PaginatedResult[domain.Client]{}, err
 	}
 
-	return &client, nil
+	return r.crud.List(ctx, pagination, scanClient, where, orderBy, args...)
 }
 
-func (r *ClientRepository) List(ctx context.Context, pagination baseRepo.PaginationParams) (baseRepo.PaginatedResult[domain.Client], error) {
-	return r.crud.List(ctx, pagination, scanClient, "", "created_at DESC")
+func (r *ClientRepository) getMany(ctx context.Context, ids []int) ([]domain.Client, error) {
+	return r.crud.GetMany(ctx, ids, scanClient)
 }
 
+func (r *ClientRepository) ListKeyset(ctx context.Context, params baseRepo.CursorParams, opts baseRepo.ListOptions) (baseRepo.CursorResult[domain.Client], error) {
+	if opts.Sort != "" {
+		return baseRepo.CursorResult[domain.Client]{}, domain.ErrInvalidInput.Explain("sort is not supported with cursor pagination")
+	}
+
+	where, _, args, err := clientFilters.Compile(opts, 0)
+	if err != nil {
+		return baseRepo.CursorResult[domain.Client]{}, err
+	}
+
+	return r.crud.ListKeyset(ctx, params, scanClient, clientKey, where, args...)
+}
+
+// Update writes the client if it is still at client.Version and bumps the
+// version
 func (r *ClientRepository) Update(ctx context.Context, client *domain.Client) error {
 	query := `UPDATE clients
-			  SET full_name = $1, email = $2, birth_date = $3, country = $4
-			  WHERE id = $5`
+			  SET full_name = $1, email = $2, birth_date = $3, country = $4,
+				  version = version + 1
+			  WHERE id = $5 AND version = $6 RETURNING version`
 
-	result, err := r.DB().Exec(ctx, query, client.FullName, client.Email,
-		client.BirthDate, client.Country, client.ID)
+	err := r.DB().QueryRow(ctx, query, client.FullName, client.Email,
+		client.BirthDate, client.Country, client.ID, client.Version).Scan(&client.Version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return r.crud.Missed(ctx, client.ID)
+	}
 	if err != nil {
 		return r.HandleError(err)
 	}
 
-	if result.RowsAffected() == 0 {
-		return domain.ErrNotFound
-	}
-
 	// Cache
-	data, _ := json.Marshal(client)
-	r.Redis().HSet(ctx, clientsHash, strconv.Itoa(client.ID), data)
+	clientCache.Invalidate(ctx, client.ID)
 
 	return nil
 }
 
-func (r *ClientRepository) Delete(ctx context.Context, id int) error {
-	err := r.crud.Delete(ctx, id)
+// Delete removes the client if it is still at version, zero skips the check
+func (r *ClientRepository) Delete(ctx context.Context, id, version int) error {
+	err := r.crud.DeleteVersion(ctx, id, version)
 	if err != nil {
 		return err
     }
 
 	// Cache
-	r.Redis().HDel(ctx, clientsHash, strconv.Itoa(id))
-	r.Redis().ZRem(ctx, clientsList, strconv.Itoa(id))
+	clientCache.Invalidate(ctx, id)
+	clientIndex.Remove(ctx, id)
 
 	return nil
 }
\ No newline at end of file
@@ -2,28 +2,61 @@ package repository
 
 import (
 	"context"
-	"encoding/json"
-	"strconv"
+	"errors"
+	"time"
 
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/redis/go-redis/v9"
 
 	"api/internal/domain"
 	baseRepo "api/pkg/repository"
 )
 
-const (
-	creditsHash = "credits"
-	creditsList = "credits:list"
-)
+// credits change status often, entries are kept short
+var creditCache = baseRepo.NewCache[domain.Credit](baseRepo.CacheOptions{
+	Name:        "credits",
+	TTL:         5 * time.Minute,
+	NegativeTTL: 30 * time.Second,
+	LocalTTL:    time.Second,
+})
+
+var creditIndex = baseRepo.NewListIndex(baseRepo.ListIndexOptions{
+	Key:   "credits:list",
+	Table: "credits",
+	Depth: listIndexDepth,
+}, creditCache, creditKey)
+
+var creditFilters = baseRepo.FilterSet{
+	Rules: map[string]baseRepo.FilterRule{
+		"status":           {Column: "status", Op: baseRepo.OpEq},
+		"credit_type":      {Column: "credit_type", Op: baseRepo.OpEq},
+		"bank_id":          {Column: "bank_id", Op: baseRepo.OpEq},
+		"client_id":        {Column: "client_id", Op: baseRepo.OpEq},
+		"term_months_from": {Column: "term_months", Op: baseRepo.OpGte},
+		"term_months_to":   {Column: "term_months", Op: baseRepo.OpLte},
+		"min_payment_from": {Column: "min_payment", Op: baseRepo.OpGte},
+		"min_payment_to":   {Column: "min_payment", Op: baseRepo.OpLte},
+		"max_payment_from": {Column: "max_payment", Op: baseRepo.OpGte},
+		"max_payment_to":   {Column: "max_payment", Op: baseRepo.OpLte},
+		"created_from":     {Column: "created_at", Op: baseRepo.OpGte},
+		"created_to":       {Column: "created_at", Op: baseRepo.OpThroughDate},
+	},
+	Sortable: map[string]string{
+		"id":          "id",
+		"created_at":  "created_at",
+		"term_months": "term_months",
+		"min_payment": "min_payment",
+		"max_payment": "max_payment",
+		"status":      "status",
+	},
+	DefaultSort: "created_at DESC, id DESC",
+}
 
 type CreditRepository struct {
 	*baseRepo.BaseRepository
 	crud *baseRepo.CRUD[domain.Credit]
 }
 
-func NewCreditRepository(db *pgxpool.Pool) *CreditRepository {
+func NewCreditRepository(db baseRepo.Querier) *CreditRepository {
 	return &CreditRepository{
 		BaseRepository: baseRepo.NewBaseRepository(db),
 		crud:           baseRepo.NewCRUD[domain.Credit](db, "credits"),
@@ -34,92 +67,144 @@ func scanCredit(row pgx.Row) (domain.Credit, error) {
 	var credit domain.Credit
 	err := row.Scan(&credit.ID, &credit.ClientID, &credit.BankID,
 		&credit.MinPayment, &credit.MaxPayment, &credit.TermMonths,
-		&credit.CreditType, &credit.Status, &credit.CreatedAt)
+		&credit.CreditType, &credit.Status, &credit.CreatedAt,
+		&credit.StatusReason, &credit.RuleSetID, &credit.RuleSetVersion,
+		&credit.Version)
 
 	return credit, err
 }
 
+func creditKey(credit domain.Credit) (time.Time, int) {
+	return credit.CreatedAt, credit.ID
+}
+
 func (r *CreditRepository) Create(ctx context.Context, credit *domain.Credit) error {
 	query := `INSERT INTO credits (client_id, bank_id, min_payment, max_payment,
-							term_months, credit_type, status, created_at)
-			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`
+							term_months, credit_type, status, created_at,
+							rule_set_id, rule_set_version)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id, version`
 
 	err := r.DB().QueryRow(ctx, query, credit.ClientID, credit.BankID,
 		credit.MinPayment, credit.MaxPayment, credit.TermMonths,
-		credit.CreditType, credit.Status, credit.CreatedAt).Scan(&credit.ID)
+		credit.CreditType, credit.Status, credit.CreatedAt,
+		credit.RuleSetID, credit.RuleSetVersion).Scan(&credit.ID, &credit.Version)
     if err != nil {
         return r.HandleError(err)
     }
 
-	// Cache
-    data, _ := json.Marshal(credit)
-    r.Redis().HSet(ctx, creditsHash, strconv.Itoa(credit.ID), data)
-    r.Redis().ZAdd(ctx, creditsList, redis.Z{Score: float64(credit.CreatedAt.Unix()), Member: strconv.Itoa(credit.ID)})
+	// Cache, the id may be remembered as missing
+	creditCache.Invalidate(ctx, credit.ID)
+	creditIndex.Add(ctx, *credit)
 
 	return r.HandleError(err)
 }
 
 func (r *CreditRepository) GetByID(ctx context.Context, id int) (*domain.Credit, error) {
-	// Try cache
-    data, err := r.Redis().HGet(ctx, creditsHash, strconv.Itoa(id)).Bytes()
-    if err == nil {
-        var credit domain.Credit
-        if json.Unmarshal(data, &credit) == nil {
-            return &credit, nil
-        }
-    }
-
-	// DB
-	credit, err := r.crud.GetByID(ctx, id, scanCredit)
-
+	credit, err := creditCache.Get(ctx, id, func(ctx context.Context) (domain.Credit, error) {
+		return r.crud.GetByID(ctx, id, scanCredit)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-    // Cache
-	if data, err := json.Marshal(credit); err == nil {
-		r.Redis().HSet(ctx, creditsHash, strconv.Itoa(id), data)
+	return &credit, nil
+}
+
+func (r *CreditRepository) List(ctx context.Context, pagination baseRepo.PaginationParams, opts baseRepo.ListOptions) (baseRepo.PaginatedResult[domain.Credit], error) {
+	// the default listing, newest first, is served from the index
+	if len(opts.Filters) == 0 && opts.Sort == "" {
+		if page, ok := creditIndex.Page(ctx, pagination, r.getMany); ok {
+			return page, nil
+		}
 	}
 
-	return &credit, nil
+	where, orderBy, args, err := creditFilters.Compile(opts, 0)
+	if err != nil {
+		return baseRepo.PaginatedResult[domain.Credit]{}, err
+	}
+
+	return r.crud.List(ctx, pagination, scanCredit, where, orderBy, args...)
 }
 
-func (r *CreditRepository) List(ctx context.Context, pagination baseRepo.PaginationParams) (baseRepo.PaginatedResult[domain.Credit], error) {
-	return r.crud.List(ctx, pagination, scanCredit, "", "created_at DESC")
+func (r *CreditRepository) getMany(ctx context.Context, ids []int) ([]domain.Credit, error) {
+	return r.crud.GetMany(ctx, ids, scanCredit)
 }
 
+func (r *CreditRepository) ListKeyset(ctx context.Context, params baseRepo.CursorParams, opts baseRepo.ListOptions) (baseRepo.CursorResult[domain.Credit], error) {
+	if opts.Sort != "" {
+		return baseRepo.CursorResult[domain.Credit]{}, domain.ErrInvalidInput.Explain("sort is not supported with cursor pagination")
+	}
+
+	where, _, args, err := creditFilters.Compile(opts, 0)
+	if err != nil {
+		return baseRepo.CursorResult[domain.Credit]{}, err
+	}
+
+	return r.crud.ListKeyset(ctx, params, scanCredit, creditKey, where, args...)
+}
+
+// Update writes the credit if it is still at credit.Version and bumps the
+// version
 func (r *CreditRepository) Update(ctx context.Context, credit *domain.Credit) error {
+	// status is left out on purpose, it only changes through UpdateStatus
 	query := `UPDATE credits
 			  SET min_payment = $1, max_payment = $2, term_months = $3,
-				  credit_type = $4, status = $5
-			  WHERE id = $6`
-
-	result, err := r.DB().Exec(ctx, query, credit.MinPayment, credit.MaxPayment,
-		credit.TermMonths, credit.CreditType, credit.Status, credit.ID)
+				  credit_type = $4, version = version + 1
+			  WHERE id = $5 AND version = $6 RETURNING version`
 
+	err := r.DB().QueryRow(ctx, query, credit.MinPayment, credit.MaxPayment,
+		credit.TermMonths, credit.CreditType, credit.ID, credit.Version).Scan(&credit.Version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return r.crud.Missed(ctx, credit.ID)
+	}
 	if err != nil {
 		return r.HandleError(err)
 	}
 
-	if result.RowsAffected() == 0 {
-		return domain.ErrNotFound
+	creditCache.Invalidate(ctx, credit.ID)
+
+	return nil
+}
+
+// UpdateStatus persists a status transition. The update only applies while
+// the row is still in the from status, so two concurrent transitions of the
+// same credit cannot both succeed.
+func (r *CreditRepository) UpdateStatus(ctx context.Context, credit *domain.Credit, from string) error {
+	query := `UPDATE credits SET status = $1, status_reason = $2, version = version + 1
+			  WHERE id = $3 AND status = $4 RETURNING version`
+
+	err := r.DB().QueryRow(ctx, query, credit.Status, credit.StatusReason, credit.ID, from).Scan(&credit.Version)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return r.HandleError(err)
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		exists, err := r.crud.Exists(ctx, credit.ID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return domain.ErrNotFound
+		}
+
+		return domain.ErrInvalidTransition.Explain("credit %d is no longer %s", credit.ID, from)
 	}
 
-    data, _ := json.Marshal(credit)
-	r.Redis().HSet(ctx, creditsHash, credit.ID, data)
+	creditCache.Invalidate(ctx, credit.ID)
 
 	return nil
 }
 
-func (r *CreditRepository) Delete(ctx context.Context, id int) error {
-	err := r.crud.Delete(ctx, id)
+// Delete removes the credit if it is still at version, zero skips the check
+func (r *CreditRepository) Delete(ctx context.Context, id, version int) error {
+	err := r.crud.DeleteVersion(ctx, id, version)
     if err != nil {
         return err
     }
 
     // Cache
-    r.Redis().HDel(ctx, creditsHash, strconv.Itoa(id))
-    r.Redis().ZRem(ctx, creditsList, strconv.Itoa(id))
+	creditCache.Invalidate(ctx, id)
+	creditIndex.Remove(ctx, id)
 
     return nil
 }
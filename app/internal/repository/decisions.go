@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/jackc/pgx/v5"
+
+	"api/internal/domain"
+	baseRepo "api/pkg/repository"
+)
+
+type DecisionRepository struct {
+	*baseRepo.BaseRepository
+}
+
+func NewDecisionRepository(db baseRepo.Querier) *DecisionRepository {
+	return &DecisionRepository{
+		BaseRepository: baseRepo.NewBaseRepository(db),
+	}
+}
+
+func scanDecision(row pgx.Row) (domain.Decision, error) {
+	var (
+		decision domain.Decision
+		factors  []byte
+	)
+
+	err := row.Scan(&decision.ID, &decision.CreditID, &decision.ClientID,
+		&decision.BankID, &decision.RuleSetID, &decision.RuleSetVersion,
+		&decision.Score, &decision.Threshold, &decision.Eligible,
+		&factors, &decision.CreatedAt)
+	if err != nil {
+		return decision, err
+	}
+
+	err = json.Unmarshal(factors, &decision.Factors)
+
+	return decision, err
+}
+
+func (r *DecisionRepository) Create(ctx context.Context, decision *domain.Decision) error {
+	factors, err := json.Marshal(decision.Factors)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO eligibility_decisions (credit_id, client_id, bank_id,
+							rule_set_id, rule_set_version, score, threshold,
+							eligible, factors, created_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id`
+
+	err = r.DB().QueryRow(ctx, query, decision.CreditID, decision.ClientID,
+		decision.BankID, decision.RuleSetID, decision.RuleSetVersion,
+		decision.Score, decision.Threshold, decision.Eligible,
+		factors, decision.CreatedAt).Scan(&decision.ID)
+
+	return r.HandleError(err)
+}
+
+// GetByCreditID returns the latest decision recorded for a credit
+func (r *DecisionRepository) GetByCreditID(ctx context.Context, creditID int) (*domain.Decision, error) {
+	query := `SELECT id, credit_id, client_id, bank_id, rule_set_id, rule_set_version,
+					 score, threshold, eligible, factors, created_at
+			  FROM eligibility_decisions
+			  WHERE credit_id = $1
+			  ORDER BY created_at DESC, id DESC
+			  LIMIT 1`
+
+	decision, err := scanDecision(r.Reader(ctx).QueryRow(ctx, query, creditID))
+	if err != nil {
+		return nil, r.HandleError(err)
+	}
+
+	return &decision, nil
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/jackc/pgx/v5"
+
+	"api/internal/eligibility"
+	baseRepo "api/pkg/repository"
+)
+
+type RuleSetRepository struct {
+	*baseRepo.BaseRepository
+}
+
+func NewRuleSetRepository(db baseRepo.Querier) *RuleSetRepository {
+	return &RuleSetRepository{
+		BaseRepository: baseRepo.NewBaseRepository(db),
+	}
+}
+
+func scanRuleSet(row pgx.Row) (eligibility.RuleSet, error) {
+	var (
+		set   eligibility.RuleSet
+		rules []byte
+	)
+
+	err := row.Scan(&set.ID, &set.BankID, &set.CreditType, &set.Version,
+		&set.Threshold, &rules, &set.CreatedAt)
+	if err != nil {
+		return set, err
+	}
+
+	err = json.Unmarshal(rules, &set.Rules)
+
+	return set, err
+}
+
+// LoadActive returns the latest active version of every (bank, credit type) scope
+func (r *RuleSetRepository) LoadActive(ctx context.Context) ([]eligibility.RuleSet, error) {
+	query := `SELECT DISTINCT ON (bank_id, credit_type)
+					 id, bank_id, credit_type, version, threshold, rules, created_at
+			  FROM eligibility_rule_sets
+			  WHERE active
+			  ORDER BY bank_id, credit_type, version DESC`
+
+	rows, err := r.Reader(ctx).Query(ctx, query)
+	if err != nil {
+		return nil, r.HandleError(err)
+	}
+
+	defer rows.Close()
+
+	sets := make([]eligibility.RuleSet, 0)
+	for rows.Next() {
+		set, err := scanRuleSet(rows)
+		if err != nil {
+			return nil, r.HandleError(err)
+		}
+
+		sets = append(sets, set)
+	}
+
+	return sets, r.HandleError(rows.Err())
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"api/internal/domain"
+	baseRepo "api/pkg/repository"
+)
+
+type WebhookDeliveryRepository struct {
+	*baseRepo.BaseRepository
+	crud *baseRepo.CRUD[domain.WebhookDelivery]
+}
+
+func NewWebhookDeliveryRepository(db baseRepo.Querier) *WebhookDeliveryRepository {
+	return &WebhookDeliveryRepository{
+		BaseRepository: baseRepo.NewBaseRepository(db),
+		crud:           baseRepo.NewCRUD[domain.WebhookDelivery](db, "webhook_deliveries"),
+	}
+}
+
+func scanWebhookDelivery(row pgx.Row) (domain.WebhookDelivery, error) {
+	var delivery domain.WebhookDelivery
+	err := row.Scan(&delivery.ID, &delivery.WebhookID, &delivery.EventID,
+		&delivery.EventType, &delivery.Body, &delivery.Status, &delivery.Attempts,
+		&delivery.NextAttemptAt, &delivery.LastStatusCode, &delivery.LastError,
+		&delivery.DeliveredAt, &delivery.CreatedAt)
+
+	return delivery, err
+}
+
+func webhookDeliveryKey(delivery domain.WebhookDelivery) (time.Time, int) {
+	return delivery.CreatedAt, delivery.ID
+}
+
+// Enqueue adds a pending delivery. An event already enqueued for the webhook
+// is ignored, since the event stream may deliver it more than once.
+func (r *WebhookDeliveryRepository) Enqueue(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	query := `INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, body, created_at)
+			  VALUES ($1, $2, $3, $4, $5)
+			  ON CONFLICT (webhook_id, event_id) DO NOTHING`
+
+	_, err := r.DB().Exec(ctx, query, delivery.WebhookID, delivery.EventID,
+		delivery.EventType, delivery.Body, delivery.CreatedAt)
+
+	return r.HandleError(err)
+}
+
+// GetByID returns a delivery together with its attempt log
+func (r *WebhookDeliveryRepository) GetByID(ctx context.Context, id int) (*domain.WebhookDelivery, error) {
+	delivery, err := r.crud.GetByID(ctx, id, scanWebhookDelivery)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.Reader(ctx).Query(ctx, `SELECT id, delivery_id, status_code, error, duration_ms, attempted_at
+									FROM webhook_attempts WHERE delivery_id = $1 ORDER BY id`, id)
+	if err != nil {
+		return nil, r.HandleError(err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var attempt domain.WebhookAttempt
+		if err := rows.Scan(&attempt.ID, &attempt.DeliveryID, &attempt.StatusCode,
+			&attempt.Error, &attempt.DurationMs, &attempt.AttemptedAt); err != nil {
+			return nil, r.HandleError(err)
+		}
+
+		delivery.AttemptLog = append(delivery.AttemptLog, attempt)
+	}
+
+	return &delivery, r.HandleError(rows.Err())
+}
+
+func (r *WebhookDeliveryRepository) List(ctx context.Context, webhookID int, pagination baseRepo.PaginationParams) (baseRepo.PaginatedResult[domain.WebhookDelivery], error) {
+	return r.crud.List(ctx, pagination, scanWebhookDelivery, "webhook_id = $1", "created_at DESC, id DESC", webhookID)
+}
+
+func (r *WebhookDeliveryRepository) ListKeyset(ctx context.Context, webhookID int, params baseRepo.CursorParams) (baseRepo.CursorResult[domain.WebhookDelivery], error) {
+	return r.crud.ListKeyset(ctx, params, scanWebhookDelivery, webhookDeliveryKey, "webhook_id = $1", webhookID)
+}
+
+// ClaimDue leases up to limit due deliveries for the given duration, so
+// several dispatchers can run without posting the same delivery twice
+func (r *WebhookDeliveryRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]domain.WebhookDelivery, error) {
+	query := `UPDATE webhook_deliveries
+			  SET next_attempt_at = now() + make_interval(secs => $2)
+			  WHERE id IN (
+				  SELECT id FROM webhook_deliveries
+				  WHERE status = 'PENDING' AND next_attempt_at <= now()
+				  ORDER BY next_attempt_at
+				  LIMIT $1
+				  FOR UPDATE SKIP LOCKED
+			  )
+			  RETURNING *`
+
+	rows, err := r.DB().Query(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, r.HandleError(err)
+	}
+
+	defer rows.Close()
+
+	deliveries := make([]domain.WebhookDelivery, 0, limit)
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, r.HandleError(err)
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, r.HandleError(rows.Err())
+}
+
+// RecordAttempt logs an attempt and stores the resulting state of the delivery
+func (r *WebhookDeliveryRepository) RecordAttempt(ctx context.Context, delivery *domain.WebhookDelivery, attempt domain.WebhookAttempt) error {
+	query := `INSERT INTO webhook_attempts (delivery_id, status_code, error, duration_ms, attempted_at)
+			  VALUES ($1, $2, $3, $4, $5)`
+
+	_, err := r.DB().Exec(ctx, query, delivery.ID, attempt.StatusCode, attempt.Error,
+		attempt.DurationMs, attempt.AttemptedAt)
+	if err != nil {
+		return r.HandleError(err)
+	}
+
+	query = `UPDATE webhook_deliveries
+			 SET status = $2, attempts = $3, next_attempt_at = $4,
+				 last_status_code = $5, last_error = $6, delivered_at = $7
+			 WHERE id = $1`
+
+	_, err = r.DB().Exec(ctx, query, delivery.ID, delivery.Status, delivery.Attempts,
+		delivery.NextAttemptAt, delivery.LastStatusCode, delivery.LastError,
+		delivery.DeliveredAt)
+
+	return r.HandleError(err)
+}
+
+// Redeliver queues a delivery again with a fresh retry budget
+func (r *WebhookDeliveryRepository) Redeliver(ctx context.Context, id int) (*domain.WebhookDelivery, error) {
+	query := `UPDATE webhook_deliveries
+			  SET status = 'PENDING', attempts = 0, next_attempt_at = now()
+			  WHERE id = $1
+			  RETURNING *`
+
+	delivery, err := scanWebhookDelivery(r.DB().QueryRow(ctx, query, id))
+	if err != nil {
+		return nil, r.HandleError(err)
+	}
+
+	return &delivery, nil
+}
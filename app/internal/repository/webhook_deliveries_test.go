@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"api/internal/domain"
+	baseRepo "api/pkg/repository"
+)
+
+type noRow struct{}
+
+func (noRow) Scan(dest ...any) error {
+	return pgx.ErrNoRows
+}
+
+// routingDB stands in for database.Cluster: it routes reads through Reader,
+// every write must reach the primary methods instead
+type routingDB struct {
+	baseRepo.Querier
+	t       *testing.T
+	primary []string
+}
+
+func (db *routingDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	db.primary = append(db.primary, sql)
+	return noRow{}
+}
+
+func (db *routingDB) Reader(ctx context.Context) *pgxpool.Pool {
+	db.t.Fatal("write routed to a replica")
+	return nil
+}
+
+func TestWebhookDeliveryWritesUsePrimary(t *testing.T) {
+	db := &routingDB{t: t}
+	repo := NewWebhookDeliveryRepository(db)
+
+	_, err := repo.Redeliver(context.Background(), 7)
+	if !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Redeliver() error = %v, want ErrNotFound", err)
+	}
+
+	if len(db.primary) != 1 || !strings.Contains(db.primary[0], "UPDATE webhook_deliveries") {
+		t.Errorf("primary queries = %q, want the UPDATE", db.primary)
+	}
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+
+	"api/internal/domain"
+	baseRepo "api/pkg/repository"
+)
+
+type WebhookRepository struct {
+	*baseRepo.BaseRepository
+	crud *baseRepo.CRUD[domain.Webhook]
+}
+
+func NewWebhookRepository(db baseRepo.Querier) *WebhookRepository {
+	return &WebhookRepository{
+		BaseRepository: baseRepo.NewBaseRepository(db),
+		crud:           baseRepo.NewCRUD[domain.Webhook](db, "webhooks"),
+	}
+}
+
+func scanWebhook(row pgx.Row) (domain.Webhook, error) {
+	var hook domain.Webhook
+	err := row.Scan(&hook.ID, &hook.BankID, &hook.URL, &hook.EventTypes,
+		&hook.Secret, &hook.Active, &hook.CreatedAt)
+
+	return hook, err
+}
+
+func (r *WebhookRepository) Create(ctx context.Context, hook *domain.Webhook) error {
+	query := `INSERT INTO webhooks (bank_id, url, event_types, secret, active, created_at)
+			  VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+
+	err := r.DB().QueryRow(ctx, query, hook.BankID, hook.URL, hook.EventTypes,
+		hook.Secret, hook.Active, hook.CreatedAt).Scan(&hook.ID)
+
+	return r.HandleError(err)
+}
+
+func (r *WebhookRepository) GetByID(ctx context.Context, id int) (*domain.Webhook, error) {
+	hook, err := r.crud.GetByID(ctx, id, scanWebhook)
+	if err != nil {
+		return nil, err
+	}
+
+	return &hook, nil
+}
+
+func (r *WebhookRepository) ListByBank(ctx context.Context, bankID int) ([]domain.Webhook, error) {
+	return r.list(ctx, `SELECT * FROM webhooks WHERE bank_id = $1 ORDER BY id`, bankID)
+}
+
+// ListSubscribed returns the active webhooks of a bank that want eventType
+func (r *WebhookRepository) ListSubscribed(ctx context.Context, bankID int, eventType string) ([]domain.Webhook, error) {
+	return r.list(ctx, `SELECT * FROM webhooks
+						WHERE bank_id = $1 AND active AND $2 = ANY(event_types)
+						ORDER BY id`, bankID, eventType)
+}
+
+func (r *WebhookRepository) list(ctx context.Context, query string, args ...any) ([]domain.Webhook, error) {
+	rows, err := r.Reader(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, r.HandleError(err)
+	}
+
+	defer rows.Close()
+
+	hooks := make([]domain.Webhook, 0)
+	for rows.Next() {
+		hook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, r.HandleError(err)
+		}
+
+		hooks = append(hooks, hook)
+	}
+
+	return hooks, r.HandleError(rows.Err())
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+
 	"api/internal/domain"
 	"api/internal/middleware"
 	"api/internal/repository"
@@ -37,39 +39,57 @@ func (bankService) Get(ctx context.Context, id int) (*domain.Bank, error) {
 	return repo.GetByID(ctx, id)
 }
 
-func (bankService) Update(ctx context.Context, id int, name, bankType *string) (*domain.Bank, error) {
+// Update changes the bank if it is at version, zero updates any version
+func (bankService) Update(ctx context.Context, id, version int, name, bankType *string) (*domain.Bank, error) {
 	pool := middleware.GetDB(ctx)
 	repo := repository.NewBankRepository(pool)
 
-	bank, err := repo.GetByID(ctx, id)
-	if err != nil {
-		return nil, err
-	}
+	var bank *domain.Bank
 
-	if name != nil {
-		bank.Name = *name
-	}
-	if bankType != nil {
-		bank.Type = *bankType
-	}
+	err := baseRepo.WithTx(ctx, pool, func(ctx context.Context) error {
+		var err error
+		bank, err = repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := domain.CheckVersion(bank, version); err != nil {
+			return err
+		}
 
-	if err := repo.Update(ctx, bank); err != nil {
+		if name != nil {
+			bank.Name = *name
+		}
+		if bankType != nil {
+			bank.Type = *bankType
+		}
+
+		return repo.Update(ctx, bank)
+	}, baseRepo.WithIsolation(pgx.RepeatableRead))
+	if err != nil {
 		return nil, err
 	}
 
 	return bank, nil
 }
 
-func (bankService) Delete(ctx context.Context, id int) error {
+func (bankService) Delete(ctx context.Context, id, version int) error {
 	pool := middleware.GetDB(ctx)
 	repo := repository.NewBankRepository(pool)
-	return repo.Delete(ctx, id)
+	return repo.Delete(ctx, id, version)
 }
 
-func (bankService) List(ctx context.Context, page, pageSize int) (interface{}, error) {
+func (bankService) List(ctx context.Context, page, pageSize int, opts baseRepo.ListOptions) (interface{}, error) {
 	pool := middleware.GetDB(ctx)
 	repo := repository.NewBankRepository(pool)
 
 	pagination := baseRepo.NewPaginationParams(page, pageSize)
-	return repo.List(ctx, pagination)
+	return repo.List(ctx, pagination, opts)
+}
+
+func (bankService) ListByCursor(ctx context.Context, params baseRepo.CursorParams, opts baseRepo.ListOptions) (interface{}, error) {
+	pool := middleware.GetDB(ctx)
+	repo := repository.NewBankRepository(pool)
+
+	return repo.ListKeyset(ctx, params, opts)
 }
\ No newline at end of file
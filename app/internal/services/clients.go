@@ -4,6 +4,8 @@ import (
 	"context"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+
 	"api/internal/domain"
 	"api/internal/middleware"
 	"api/internal/repository"
@@ -32,37 +34,58 @@ func (clientService) Get(ctx context.Context, id int) (*domain.Client, error) {
 	return repo.GetByID(ctx, id)
 }
 
-func (clientService) Update(ctx context.Context, id int, fullName, email, birthDate, country *string) (*domain.Client, error) {
-	repo := repository.NewClientRepository(middleware.GetDB(ctx))
+// Update changes the client if it is at version, zero updates any version
+func (clientService) Update(ctx context.Context, id, version int, fullName, email, birthDate, country *string) (*domain.Client, error) {
+	db := middleware.GetDB(ctx)
+	repo := repository.NewClientRepository(db)
+
+	var client *domain.Client
+
+	err := baseRepo.WithTx(ctx, db, func(ctx context.Context) error {
+		var err error
+		client, err = repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
 
-	client, err := repo.GetByID(ctx, id)
+		if err := domain.CheckVersion(client, version); err != nil {
+			return err
+		}
+
+		if fullName != nil {
+			client.FullName = *fullName
+		}
+		if email != nil {
+			client.Email = *email
+		}
+		if birthDate != nil {
+			client.BirthDate = *birthDate
+		}
+		if country != nil {
+			client.Country = *country
+		}
+
+		return repo.Update(ctx, client)
+	}, baseRepo.WithIsolation(pgx.RepeatableRead))
 	if err != nil {
 		return nil, err
 	}
 
-	if fullName != nil {
-		client.FullName = *fullName
-	}
-	if email != nil {
-		client.Email = *email
-	}
-	if birthDate != nil {
-		client.BirthDate = *birthDate
-	}
-	if country != nil {
-		client.Country = *country
-	}
-
-	return client, repo.Update(ctx, client)
+	return client, nil
 }
 
-func (clientService) Delete(ctx context.Context, id int) error {
+func (clientService) Delete(ctx context.Context, id, version int) error {
 	repo := repository.NewClientRepository(middleware.GetDB(ctx))
-	return repo.Delete(ctx, id)
+	return repo.Delete(ctx, id, version)
 }
 
-func (clientService) List(ctx context.Context, page, pageSize int) (interface{}, error) {
+func (clientService) List(ctx context.Context, page, pageSize int, opts baseRepo.ListOptions) (interface{}, error) {
 	repo := repository.NewClientRepository(middleware.GetDB(ctx))
 	pagination := baseRepo.NewPaginationParams(page, pageSize)
-	return repo.List(ctx, pagination)
+	return repo.List(ctx, pagination, opts)
+}
+
+func (clientService) ListByCursor(ctx context.Context, params baseRepo.CursorParams, opts baseRepo.ListOptions) (interface{}, error) {
+	repo := repository.NewClientRepository(middleware.GetDB(ctx))
+	return repo.ListKeyset(ctx, params, opts)
 }
\ No newline at end of file
@@ -6,10 +6,19 @@ import (
 	"sync"
 	"errors"
 
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
 	"api/internal/domain"
+	"api/internal/eligibility"
 	"api/internal/middleware"
 	"api/internal/repository"
 	"api/internal/events"
+	"api/internal/outbox"
+	"api/internal/tracing"
+	"api/pkg/database"
 	baseRepo "api/pkg/repository"
 )
 
@@ -18,15 +27,6 @@ var CreditService = creditService{}
 type creditService struct{}
 
 func (s creditService) Create(ctx context.Context, clientID, bankID int, minPayment, maxPayment float64, termMonths int, creditType string) (*domain.Credit, error) {
-    score, err := s.ValidateEligibility(ctx, clientID, bankID)
-    if err != nil {
-        return nil, err
-    }
-
-    if score < 50 {
-        return nil, domain.ErrNotEligible
-    }
-
 	credit := &domain.Credit{
 		ClientID:   clientID,
 		BankID:     bankID,
@@ -34,27 +34,46 @@ func (s creditService) Create(ctx context.Context, clientID, bankID int, minPaym
 		MaxPayment: maxPayment,
 		TermMonths: termMonths,
 		CreditType: creditType,
-		Status:     "PENDING",
+		Status:     domain.CreditPending,
 		CreatedAt:  time.Now().UTC(),
 	}
 
-	repo := repository.NewCreditRepository(middleware.GetDB(ctx))
+	// a client or bank created a moment ago may not be on the replicas yet
+	result, err := s.ValidateEligibility(database.WithMaxStaleness(ctx, 0), *credit)
+    if err != nil {
+        return nil, err
+    }
 
-	if err := repo.Create(ctx, credit); err != nil {
-		return nil, err
-	}
+	db := middleware.GetDB(ctx)
+	decision := newDecision(*credit, result)
 
-	if pub := middleware.GetPublisher(ctx); pub != nil {
-		pub.Publish(ctx, events.Event{
-			Type:      "CreditCreated",
-			Timestamp: time.Now(),
-			Payload: events.CreditCreatedEvent{
-				CreditID:   credit.ID,
-				ClientID:   credit.ClientID,
-				BankID:     credit.BankID,
-				CreditType: credit.CreditType,
-			},
-		})
+    if !result.Eligible {
+        if err := repository.NewDecisionRepository(db).Create(ctx, &decision); err != nil {
+            return nil, err
+        }
+        return nil, &domain.NotEligibleError{Decision: decision}
+    }
+
+    if result.RuleSetID != 0 {
+        credit.RuleSetID = &result.RuleSetID
+        credit.RuleSetVersion = &result.RuleSetVersion
+    }
+
+	// Credit, decision and event are committed together
+	err = baseRepo.WithTx(ctx, db, func(ctx context.Context) error {
+		if err := repository.NewCreditRepository(db).Create(ctx, credit); err != nil {
+			return err
+		}
+
+		decision.CreditID = &credit.ID
+		if err := repository.NewDecisionRepository(db).Create(ctx, &decision); err != nil {
+			return err
+		}
+
+		return outbox.NewStore(db).Add(ctx, events.CreditCreated(*credit))
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return credit, nil
@@ -65,199 +84,262 @@ func (s creditService) Get(ctx context.Context, id int) (*domain.Credit, error)
 	return repo.GetByID(ctx, id)
 }
 
-func (s creditService) Update(ctx context.Context, id int, minPayment, maxPayment *float64, termMonths *int, creditType, status *string) (*domain.Credit, error) {
-	repo := repository.NewCreditRepository(middleware.GetDB(ctx))
+// Decision returns the eligibility decision the credit was granted with
+func (s creditService) Decision(ctx context.Context, id int) (*domain.Decision, error) {
+	repo := repository.NewDecisionRepository(middleware.GetDB(ctx))
+	return repo.GetByCreditID(ctx, id)
+}
 
-	credit, err := repo.GetByID(ctx, id)
+// CheckEligibility evaluates an application without creating a credit or
+// recording the decision
+func (s creditService) CheckEligibility(ctx context.Context, clientID, bankID int, minPayment, maxPayment float64, termMonths int, creditType string) (*domain.Decision, error) {
+	credit := domain.Credit{
+		ClientID:   clientID,
+		BankID:     bankID,
+		MinPayment: minPayment,
+		MaxPayment: maxPayment,
+		TermMonths: termMonths,
+		CreditType: creditType,
+	}
+
+	// as for Create, the client or bank may not be on the replicas yet
+	result, err := s.ValidateEligibility(database.WithMaxStaleness(ctx, 0), credit)
 	if err != nil {
 		return nil, err
 	}
 
-	if minPayment != nil {
-		credit.MinPayment = *minPayment
-	}
-	if maxPayment != nil {
-		credit.MaxPayment = *maxPayment
-	}
-	if termMonths != nil {
-		credit.TermMonths = *termMonths
-	}
-	if creditType != nil {
-		credit.CreditType = *creditType
-	}
-	if status != nil {
-		credit.Status = *status
-	}
+	decision := newDecision(credit, result)
 
-    if err := repo.Update(ctx, credit); err != nil {
+	return &decision, nil
+}
+
+// Update changes the credit if it is at version, zero updates any version
+func (s creditService) Update(ctx context.Context, id, version int, minPayment, maxPayment *float64, termMonths *int, creditType *string) (*domain.Credit, error) {
+	db := middleware.GetDB(ctx)
+	repo := repository.NewCreditRepository(db)
+
+	var credit *domain.Credit
+
+	// read-modify-write, a concurrent update makes the transaction retry on
+	// fresh data instead of being overwritten
+	err := baseRepo.WithTx(ctx, db, func(ctx context.Context) error {
+		var err error
+		credit, err = repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := domain.CheckVersion(credit, version); err != nil {
+			return err
+		}
+
+		if err := credit.CheckEditable(); err != nil {
+			return err
+		}
+
+		if minPayment != nil {
+			credit.MinPayment = *minPayment
+		}
+		if maxPayment != nil {
+			credit.MaxPayment = *maxPayment
+		}
+		if termMonths != nil {
+			credit.TermMonths = *termMonths
+		}
+		if creditType != nil {
+			credit.CreditType = *creditType
+		}
+
+		if err := credit.CheckTerms(); err != nil {
+			return err
+		}
+
+		return repo.Update(ctx, credit)
+	}, baseRepo.WithIsolation(pgx.RepeatableRead))
+	if err != nil {
 		return nil, err
 	}
 
-    if credit.Status == "APPROVED" {
-        if pub := middleware.GetPublisher(ctx); pub != nil {
-            pub.Publish(ctx, events.Event{
-                Type:      "CreditApproved",
-                Timestamp: time.Now(),
-                Payload: events.CreditApprovedEvent{
-                    CreditID:   credit.ID,
-                    ClientID:   credit.ClientID,
-                    ApprovedAt: time.Now(),
-                },
-            })
-        }
-    }
+	return credit, nil
+}
+
+// Transition moves a credit along its lifecycle and publishes exactly one
+// event for the transition
+func (s creditService) Transition(ctx context.Context, id int, status string, reason *string) (*domain.Credit, error) {
+	db := middleware.GetDB(ctx)
+	repo := repository.NewCreditRepository(db)
+
+	var credit *domain.Credit
+
+	err := baseRepo.WithTx(ctx, db, func(ctx context.Context) error {
+		var err error
+		credit, err = repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		from := credit.Status
+
+		if err := credit.TransitionTo(status, reason); err != nil {
+			return err
+		}
+
+		if err := repo.UpdateStatus(ctx, credit, from); err != nil {
+			return err
+		}
+
+		return outbox.NewStore(db).Add(ctx, events.CreditTransitionEvent(*credit, from, time.Now().UTC()))
+	}, baseRepo.WithIsolation(pgx.RepeatableRead))
+	if err != nil {
+		return nil, err
+	}
 
 	return credit, nil
 }
 
-func (s creditService) Delete(ctx context.Context, id int) error {
+func (s creditService) Delete(ctx context.Context, id, version int) error {
 	repo := repository.NewCreditRepository(middleware.GetDB(ctx))
-	return repo.Delete(ctx, id)
+	return repo.Delete(ctx, id, version)
 }
 
-func (s creditService) List(ctx context.Context, page, pageSize int) (interface{}, error) {
+func (s creditService) List(ctx context.Context, page, pageSize int, opts baseRepo.ListOptions) (interface{}, error) {
 	repo := repository.NewCreditRepository(middleware.GetDB(ctx))
 	pagination := baseRepo.NewPaginationParams(page, pageSize)
-	return repo.List(ctx, pagination)
+	return repo.List(ctx, pagination, opts)
+}
+
+func (s creditService) ListByCursor(ctx context.Context, params baseRepo.CursorParams, opts baseRepo.ListOptions) (interface{}, error) {
+	repo := repository.NewCreditRepository(middleware.GetDB(ctx))
+	return repo.ListKeyset(ctx, params, opts)
 }
 
-func (s creditService) ValidateEligibility(ctx context.Context, clientID, bankID int) (int, error) {
+// ValidateEligibility scores a credit application with the rule set that
+// applies to its bank and credit type. Client and bank are fetched concurrently.
+func (s creditService) ValidateEligibility(ctx context.Context, credit domain.Credit) (eligibility.Result, error) {
+    ctx, span := tracing.Tracer().Start(ctx, "eligibility",
+        trace.WithAttributes(
+            attribute.Int("client.id", credit.ClientID),
+            attribute.Int("bank.id", credit.BankID),
+            attribute.String("credit.type", credit.CreditType),
+        ),
+    )
+    defer span.End()
+
     ctx, cancel := context.WithCancel(ctx)
     defer cancel()
 
-    type res struct{
-        score int;
-        err error
-    }
+    db := middleware.GetDB(ctx)
 
-    ch := make(chan res, 3)
-    var wg sync.WaitGroup
+    var (
+        wg        sync.WaitGroup
+        client    *domain.Client
+        bank      *domain.Bank
+        clientErr error
+        bankErr   error
+    )
 
-    db := middleware.GetDB(ctx)
+    wg.Add(2)
 
-    // Check that age is between 18 and 70
-    wg.Add(1)
     go func() {
         defer wg.Done()
 
-        client, err := repository.NewClientRepository(db).GetByID(ctx, clientID)
-        if err != nil {
-            select {
-                case ch <- res{0, err}:
-                case <-ctx.Done():
-            }
-            return
-        }
-
-        birth, err := time.Parse("2000-01-02", client.BirthDate)
-        if err != nil {
-            ch <- res{0, err}
-            return
-        }
-
-        age := int(time.Since(birth).Hours() / 8760)
-        score := 0
-
-        if age >= 18 {
-            if age <= 70 {
-                score = 35
-            } else {
-                score = 15
-            }
-        }
-
-        select {
-            case ch <- res{score, nil}:
-            case <-ctx.Done():
+        client, clientErr = repository.NewClientRepository(db).GetByID(ctx, credit.ClientID)
+        if clientErr != nil {
+            cancel()
         }
     }()
 
-    // Check type of bank
-    wg.Add(1)
     go func() {
         defer wg.Done()
 
-        bank, err := repository.NewBankRepository(db).GetByID(ctx, bankID)
-        if err != nil {
-            select {
-                case ch <- res{0, err}:
-                case <-ctx.Done():
-            }
-            return
+        bank, bankErr = repository.NewBankRepository(db).GetByID(ctx, credit.BankID)
+        if bankErr != nil {
+            cancel()
         }
+    }()
 
-        score := 0
+    wg.Wait()
 
-        switch bank.Type {
-            case "PRIVATE":
-                score = 30
-            case "GOVERNMENT":
-                score = 20
-        }
+    // the application refers to them, so a missing one is not a 404
+    if errors.Is(clientErr, domain.ErrNotFound) {
+        clientErr = domain.ErrForeignKey.Explain("client_id %d", credit.ClientID)
+    }
+    if errors.Is(bankErr, domain.ErrNotFound) {
+        bankErr = domain.ErrForeignKey.Explain("bank_id %d", credit.BankID)
+    }
 
-        select {
-            case ch <- res{score, nil}:
-            case <-ctx.Done():
-        }
-    }()
+    if err := errors.Join(clientErr, bankErr); err != nil {
+        span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+        return eligibility.Result{}, err
+    }
 
-    // Check country
-    wg.Add(1)
-    go func() {
-        defer wg.Done()
+    birth, err := time.Parse(time.DateOnly, client.BirthDate)
+    if err != nil {
+        return eligibility.Result{}, err
+    }
 
-        client, err := repository.NewClientRepository(db).GetByID(ctx, clientID)
-        if err != nil {
-            select {
-                case ch <- res{0, err}:
-                case <-ctx.Done():
-            }
-            return
-        }
+    subject := eligibility.Subject{
+        Age:        ageAt(birth, time.Now().UTC()),
+        Country:    client.Country,
+        BankType:   bank.Type,
+        CreditType: credit.CreditType,
+        TermMonths: credit.TermMonths,
+        MinPayment: credit.MinPayment,
+        MaxPayment: credit.MaxPayment,
+    }
 
-        score := 10
-        switch client.Country {
-            case "USA", "Canada", "Chili":
-                score = 35
-            case "Mexico", "Brazil", "Panama":
-                score = 20
-        }
+    engine := middleware.GetEligibility(ctx)
+    if engine == nil {
+        return eligibility.Trace(ctx, eligibility.DefaultRuleSet, subject), nil
+    }
 
-        select {
-            case ch <- res{score, nil}:
-            case <-ctx.Done():
-        }
-    }()
+    return engine.EvaluateContext(ctx, credit.BankID, subject), nil
+}
 
-    go func() {
-        wg.Wait()
-        close(ch)
-    }()
+func newDecision(credit domain.Credit, result eligibility.Result) domain.Decision {
+	decision := domain.Decision{
+		ClientID:       credit.ClientID,
+		BankID:         credit.BankID,
+		RuleSetVersion: result.RuleSetVersion,
+		Score:          result.Score,
+		Threshold:      result.Threshold,
+		Eligible:       result.Eligible,
+		Factors:        make([]domain.Factor, 0, len(result.Factors)),
+		CreatedAt:      time.Now().UTC(),
+	}
 
-    var total int
-    var errs []error
-
-    done := false
-    for !done {
-        select {
-        case r, ok := <-ch:
-            if !ok {
-                done = true
-                break
-            }
-            if r.err != nil {
-                errs = append(errs, r.err)
-            } else {
-                total += r.score
-            }
-        case <-ctx.Done():
-            return total, ctx.Err()
-        }
-    }
+	if result.RuleSetID != 0 {
+		decision.RuleSetID = &result.RuleSetID
+	}
+
+	for _, f := range result.Factors {
+		factor := domain.Factor{
+			Field:        f.Field,
+			Value:        f.Value,
+			Points:       f.Points,
+			MatchedRules: make([]domain.MatchedRule, 0, len(f.Matches)),
+		}
+		for _, m := range f.Matches {
+			factor.MatchedRules = append(factor.MatchedRules, domain.MatchedRule{
+				Operator: m.Operator,
+				Value:    m.Value,
+				Points:   m.Points,
+			})
+		}
+
+		decision.Factors = append(decision.Factors, factor)
+	}
 
-    if len(errs) > 0 {
-        return 0, errors.Join(errs...)
+	return decision
+}
+
+// ageAt returns full years between birth and now
+func ageAt(birth, now time.Time) int {
+    age := now.Year() - birth.Year()
+    if now.Month() < birth.Month() || (now.Month() == birth.Month() && now.Day() < birth.Day()) {
+        age--
     }
 
-    return total, nil
-}
\ No newline at end of file
+    return age
+}
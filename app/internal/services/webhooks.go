@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"api/internal/domain"
+	"api/internal/middleware"
+	"api/internal/repository"
+	"api/internal/webhooks"
+	baseRepo "api/pkg/repository"
+)
+
+var WebhookService = webhookService{}
+
+type webhookService struct{}
+
+func (webhookService) Create(ctx context.Context, bankID int, url string, eventTypes []string, secret string) (*domain.Webhook, error) {
+	// the dispatcher refuses these too, failing early tells the caller why
+	if err := webhooks.CheckURL(url); err != nil {
+		return nil, domain.ErrInvalidInput.Explain("url: %v", err)
+	}
+
+	pool := middleware.GetDB(ctx)
+
+	if _, err := repository.NewBankRepository(pool).GetByID(ctx, bankID); err != nil {
+		return nil, err
+	}
+
+	hook := &domain.Webhook{
+		BankID:     bankID,
+		URL:        url,
+		EventTypes: eventTypes,
+		Secret:     secret,
+		Active:     true,
+		CreatedAt:  time.Now().UTC(),
+	}
+
+	if err := repository.NewWebhookRepository(pool).Create(ctx, hook); err != nil {
+		return nil, err
+	}
+
+	return hook, nil
+}
+
+func (webhookService) ListByBank(ctx context.Context, bankID int) ([]domain.Webhook, error) {
+	pool := middleware.GetDB(ctx)
+
+	if _, err := repository.NewBankRepository(pool).GetByID(ctx, bankID); err != nil {
+		return nil, err
+	}
+
+	return repository.NewWebhookRepository(pool).ListByBank(ctx, bankID)
+}
+
+// Deliveries returns the delivery log of a webhook, newest first
+func (webhookService) Deliveries(ctx context.Context, webhookID, page, pageSize int) (interface{}, error) {
+	pool := middleware.GetDB(ctx)
+
+	if _, err := repository.NewWebhookRepository(pool).GetByID(ctx, webhookID); err != nil {
+		return nil, err
+	}
+
+	pagination := baseRepo.NewPaginationParams(page, pageSize)
+	return repository.NewWebhookDeliveryRepository(pool).List(ctx, webhookID, pagination)
+}
+
+func (webhookService) DeliveriesByCursor(ctx context.Context, webhookID int, params baseRepo.CursorParams) (interface{}, error) {
+	pool := middleware.GetDB(ctx)
+
+	if _, err := repository.NewWebhookRepository(pool).GetByID(ctx, webhookID); err != nil {
+		return nil, err
+	}
+
+	return repository.NewWebhookDeliveryRepository(pool).ListKeyset(ctx, webhookID, params)
+}
+
+// Delivery returns a delivery with every attempt made so far
+func (webhookService) Delivery(ctx context.Context, id int) (*domain.WebhookDelivery, error) {
+	repo := repository.NewWebhookDeliveryRepository(middleware.GetDB(ctx))
+	return repo.GetByID(ctx, id)
+}
+
+// Redeliver puts a delivery back in the queue, whatever its current status
+func (webhookService) Redeliver(ctx context.Context, id int) (*domain.WebhookDelivery, error) {
+	repo := repository.NewWebhookDeliveryRepository(middleware.GetDB(ctx))
+	return repo.Redeliver(ctx, id)
+}
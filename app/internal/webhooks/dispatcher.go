@@ -0,0 +1,229 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"api/internal/domain"
+	"api/internal/events"
+	"api/internal/repository"
+)
+
+const (
+	baseBackoff = 10 * time.Second
+	maxBackoff  = time.Hour
+)
+
+type Options struct {
+	MaxAttempts int
+	BatchSize   int
+	Timeout     time.Duration
+	// AllowPrivate lets deliveries reach loopback and private addresses,
+	// e.g. a test receiver. Off, only public addresses are dialed.
+	AllowPrivate bool
+}
+
+// Dispatcher turns credit events into webhook deliveries and posts them,
+// retrying failures with exponential backoff
+type Dispatcher struct {
+	db     *pgxpool.Pool
+	client *http.Client
+	opts   Options
+}
+
+func NewDispatcher(db *pgxpool.Pool, opts Options) *Dispatcher {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 8
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 20
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+
+	return &Dispatcher{
+		db:     db,
+		client: newClient(opts.Timeout, opts.AllowPrivate),
+		opts:   opts,
+	}
+}
+
+// Enqueue creates a delivery of event for every webhook of the bank that is
+// subscribed to its type
+func (d *Dispatcher) Enqueue(ctx context.Context, event events.Event, bankID int) error {
+	hooks, err := repository.NewWebhookRepository(d.db).ListSubscribed(ctx, bankID, event.Type)
+	if err != nil || len(hooks) == 0 {
+		return err
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return pgx.BeginFunc(ctx, d.db, func(tx pgx.Tx) error {
+		repo := repository.NewWebhookDeliveryRepository(tx)
+
+		for _, hook := range hooks {
+			err := repo.Enqueue(ctx, &domain.WebhookDelivery{
+				WebhookID: hook.ID,
+				EventID:   event.ID,
+				EventType: event.Type,
+				Body:      body,
+				CreatedAt: time.Now().UTC(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Run posts due deliveries every interval until ctx is done
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			for {
+				n, err := d.DispatchDue(ctx)
+				if err != nil {
+					slog.Error("webhook dispatch failed", "err", err)
+				}
+				if err != nil || n < d.opts.BatchSize {
+					break
+				}
+			}
+		}
+	}
+}
+
+// DispatchDue posts one batch of due deliveries and returns its size
+func (d *Dispatcher) DispatchDue(ctx context.Context) (int, error) {
+	// the lease outlives the request timeout, so a crashed dispatcher's
+	// deliveries become due again instead of staying stuck
+	lease := d.opts.Timeout*time.Duration(d.opts.BatchSize) + time.Minute
+
+	deliveries, err := repository.NewWebhookDeliveryRepository(d.db).ClaimDue(ctx, d.opts.BatchSize, lease)
+	if err != nil {
+		return 0, err
+	}
+
+	hooks := repository.NewWebhookRepository(d.db)
+
+	for i := range deliveries {
+		delivery := &deliveries[i]
+
+		hook, err := hooks.GetByID(ctx, delivery.WebhookID)
+		if err != nil {
+			return len(deliveries), err
+		}
+
+		attempt := d.Send(ctx, *hook, *delivery)
+		d.apply(delivery, attempt)
+
+		err = pgx.BeginFunc(ctx, d.db, func(tx pgx.Tx) error {
+			return repository.NewWebhookDeliveryRepository(tx).RecordAttempt(ctx, delivery, attempt)
+		})
+		if err != nil {
+			return len(deliveries), err
+		}
+	}
+
+	return len(deliveries), nil
+}
+
+// Send posts the delivery body to the webhook and reports the outcome.
+// Only 2xx responses count as delivered.
+func (d *Dispatcher) Send(ctx context.Context, hook domain.Webhook, delivery domain.WebhookDelivery) domain.WebhookAttempt {
+	start := time.Now()
+	attempt := domain.WebhookAttempt{
+		DeliveryID:  delivery.ID,
+		AttemptedAt: start.UTC(),
+	}
+
+	fail := func(err error) domain.WebhookAttempt {
+		msg := err.Error()
+		attempt.Error = &msg
+		attempt.DurationMs = int(time.Since(start).Milliseconds())
+		return attempt
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(delivery.Body))
+	if err != nil {
+		return fail(err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "credits-webhooks/1")
+	req.Header.Set(EventIDHeader, delivery.EventID)
+	req.Header.Set(EventTypeHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, strconv.Itoa(delivery.ID))
+	req.Header.Set(SignatureHeader, Sign(hook.Secret, start, delivery.Body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fail(err)
+	}
+
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	attempt.StatusCode = &resp.StatusCode
+	attempt.DurationMs = int(time.Since(start).Milliseconds())
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg := fmt.Sprintf("unexpected status %d", resp.StatusCode)
+		attempt.Error = &msg
+	}
+
+	return attempt
+}
+
+// apply moves the delivery to its next state after an attempt
+func (d *Dispatcher) apply(delivery *domain.WebhookDelivery, attempt domain.WebhookAttempt) {
+	delivery.Attempts++
+	delivery.LastStatusCode = attempt.StatusCode
+	delivery.LastError = attempt.Error
+
+	switch {
+	case attempt.Error == nil:
+		delivery.Status = domain.DeliverySucceeded
+		delivery.DeliveredAt = &attempt.AttemptedAt
+
+	case delivery.Attempts >= d.opts.MaxAttempts:
+		delivery.Status = domain.DeliveryFailed
+
+	default:
+		delivery.NextAttemptAt = time.Now().UTC().Add(Backoff(delivery.Attempts))
+	}
+}
+
+// Backoff returns the delay after the given failed attempt, doubling from
+// ten seconds up to an hour
+func Backoff(attempt int) time.Duration {
+	delay := baseBackoff
+	for i := 1; i < attempt && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+
+	return min(delay, maxBackoff)
+}
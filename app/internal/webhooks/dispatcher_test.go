@@ -0,0 +1,125 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"api/internal/domain"
+)
+
+func TestSendSignsDelivery(t *testing.T) {
+	const secret = "s3cret"
+	body := []byte(`{"event_id":"e1","type":"CreditCreated"}`)
+
+	var verifyErr error
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		verifyErr = Verify(secret, r.Header.Get(SignatureHeader), data, time.Minute)
+
+		if r.Header.Get(EventIDHeader) != "e1" || r.Header.Get(EventTypeHeader) != "CreditCreated" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	d := NewDispatcher(nil, Options{MaxAttempts: 3, AllowPrivate: true})
+	hook := domain.Webhook{URL: receiver.URL, Secret: secret}
+	delivery := domain.WebhookDelivery{ID: 1, EventID: "e1", EventType: "CreditCreated", Body: body}
+
+	attempt := d.Send(context.Background(), hook, delivery)
+
+	if verifyErr != nil {
+		t.Fatalf("receiver could not verify signature: %v", verifyErr)
+	}
+	if attempt.Error != nil {
+		t.Fatalf("Send() error = %s", *attempt.Error)
+	}
+	if attempt.StatusCode == nil || *attempt.StatusCode != http.StatusNoContent {
+		t.Errorf("StatusCode = %v, want 204", attempt.StatusCode)
+	}
+
+	d.apply(&delivery, attempt)
+	if delivery.Status != domain.DeliverySucceeded || delivery.DeliveredAt == nil {
+		t.Errorf("delivery = %+v, want succeeded", delivery)
+	}
+}
+
+func TestSendRetriesThenFails(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	d := NewDispatcher(nil, Options{MaxAttempts: 2, AllowPrivate: true})
+	hook := domain.Webhook{URL: receiver.URL, Secret: "x"}
+	delivery := domain.WebhookDelivery{ID: 1, Status: domain.DeliveryPending, Body: []byte(`{}`)}
+
+	d.apply(&delivery, d.Send(context.Background(), hook, delivery))
+	if delivery.Status != domain.DeliveryPending || delivery.Attempts != 1 {
+		t.Fatalf("after first failure delivery = %+v, want pending", delivery)
+	}
+	if delivery.LastStatusCode == nil || *delivery.LastStatusCode != http.StatusServiceUnavailable {
+		t.Errorf("LastStatusCode = %v, want 503", delivery.LastStatusCode)
+	}
+	if wait := time.Until(delivery.NextAttemptAt); wait < 9*time.Second {
+		t.Errorf("next attempt in %v, want backoff", wait)
+	}
+
+	d.apply(&delivery, d.Send(context.Background(), hook, delivery))
+	if delivery.Status != domain.DeliveryFailed {
+		t.Errorf("after max attempts status = %s, want %s", delivery.Status, domain.DeliveryFailed)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"a":1}`)
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		secret  string
+		header  string
+		body    []byte
+		wantErr error
+	}{
+		{"valid", "k", Sign("k", now, body), body, nil},
+		{"wrong secret", "other", Sign("k", now, body), body, ErrInvalidSignature},
+		{"tampered body", "k", Sign("k", now, body), []byte(`{"a":2}`), ErrInvalidSignature},
+		{"expired", "k", Sign("k", now.Add(-time.Hour), body), body, ErrInvalidSignature},
+		{"malformed", "k", "v1=abc", body, ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.secret, tt.header, tt.body, 5*time.Minute)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 10 * time.Second},
+		{3, 40 * time.Second},
+		{9, 2560 * time.Second},
+		{10, time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := Backoff(tt.attempt); got != tt.want {
+			t.Errorf("Backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
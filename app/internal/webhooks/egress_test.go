@@ -0,0 +1,103 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"api/internal/domain"
+)
+
+func TestPublicAddress(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.18.0.5", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"fd00::1", false},
+		{"fe80::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"224.0.0.1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := PublicAddress(netip.MustParseAddr(tt.addr)); got != tt.want {
+				t.Errorf("PublicAddress(%s) = %v, want %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr error
+	}{
+		{"https://hooks.example.com/credits", nil},
+		{"https://93.184.216.34/hook", nil},
+		{"http://localhost:8080/hook", ErrForbiddenAddress},
+		{"http://127.0.0.1/hook", ErrForbiddenAddress},
+		{"http://169.254.169.254/latest/meta-data", ErrForbiddenAddress},
+		{"http://[::1]:6379", ErrForbiddenAddress},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if err := CheckURL(tt.url); !errors.Is(err, tt.wantErr) {
+				t.Errorf("CheckURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSendRefusesPrivateAddresses(t *testing.T) {
+	var reached bool
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))
+	defer receiver.Close()
+
+	d := NewDispatcher(nil, Options{})
+	hook := domain.Webhook{URL: receiver.URL, Secret: "x"}
+
+	attempt := d.Send(context.Background(), hook, domain.WebhookDelivery{ID: 1, Body: []byte(`{}`)})
+	if reached || attempt.Error == nil || !strings.Contains(*attempt.Error, ErrForbiddenAddress.Error()) {
+		t.Errorf("Send() to %s reached = %v, error = %v, want refused", receiver.URL, reached, attempt.Error)
+	}
+}
+
+func TestSendDoesNotFollowRedirects(t *testing.T) {
+	var followed bool
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		followed = true
+	}))
+	defer target.Close()
+
+	receiver := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	defer receiver.Close()
+
+	d := NewDispatcher(nil, Options{AllowPrivate: true})
+	hook := domain.Webhook{URL: receiver.URL, Secret: "x"}
+
+	attempt := d.Send(context.Background(), hook, domain.WebhookDelivery{ID: 1, Body: []byte(`{}`)})
+	if followed {
+		t.Error("redirect was followed")
+	}
+	if attempt.StatusCode == nil || *attempt.StatusCode != http.StatusTemporaryRedirect || attempt.Error == nil {
+		t.Errorf("attempt = %+v, want a failed 307", attempt)
+	}
+}
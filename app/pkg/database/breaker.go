@@ -0,0 +1,227 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half_open"
+)
+
+// ErrCircuitOpen is returned instead of sending a command while Redis is
+// considered down
+var ErrCircuitOpen = errors.New("redis circuit breaker is open")
+
+type BreakerOptions struct {
+	// Threshold is how many consecutive failures open the breaker
+	Threshold int
+	// Cooldown is how long the breaker stays open before a probe is let through
+	Cooldown time.Duration
+}
+
+// BreakerStatus is the breaker as reported by the health check
+type BreakerStatus struct {
+	State     BreakerState `json:"state"`
+	Failures  int          `json:"consecutive_failures"`
+	OpenedAt  *time.Time   `json:"opened_at,omitempty"`
+	LastError string       `json:"last_error,omitempty"`
+}
+
+// Breaker fails Redis commands fast after Threshold consecutive failures.
+// Once Cooldown passed a single command is let through as a probe: success
+// closes the breaker, failure opens it again.
+type Breaker struct {
+	opts BreakerOptions
+	now  func() time.Time
+
+	mu        sync.Mutex
+	state     BreakerState
+	failures  int
+	openedAt  time.Time
+	probing   bool
+	lastError error
+	onChange  func(from, to BreakerState)
+}
+
+func NewBreaker(opts BreakerOptions) *Breaker {
+	if opts.Threshold <= 0 {
+		opts.Threshold = 5
+	}
+	if opts.Cooldown <= 0 {
+		opts.Cooldown = 5 * time.Second
+	}
+
+	return &Breaker{opts: opts, now: time.Now, state: BreakerClosed}
+}
+
+// Allow returns ErrCircuitOpen when a command must not be sent
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.opts.Cooldown {
+			return ErrCircuitOpen
+		}
+		b.transition(BreakerHalfOpen)
+		b.probing = true
+		return nil
+
+	case BreakerHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+		return nil
+	}
+
+	return nil
+}
+
+// Record feeds the outcome of an allowed command to the breaker
+func (b *Breaker) Record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+
+	// a caller giving up says nothing about Redis
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+
+	if !failure(err) {
+		b.failures = 0
+		if b.state != BreakerClosed {
+			b.transition(BreakerClosed)
+		}
+		return
+	}
+
+	b.failures++
+	b.lastError = err
+
+	if b.state == BreakerHalfOpen || b.failures >= b.opts.Threshold {
+		b.openedAt = b.now()
+		if b.state != BreakerOpen {
+			b.transition(BreakerOpen)
+		}
+	}
+}
+
+// Trip opens the breaker right away, e.g. when Redis is down at boot
+func (b *Breaker) Trip(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = max(b.failures, b.opts.Threshold)
+	b.lastError = err
+	b.openedAt = b.now()
+	if b.state != BreakerOpen {
+		b.transition(BreakerOpen)
+	}
+}
+
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+func (b *Breaker) Status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := BreakerStatus{State: b.state, Failures: b.failures}
+	if b.state != BreakerClosed {
+		openedAt := b.openedAt
+		status.OpenedAt = &openedAt
+	}
+	if b.lastError != nil {
+		status.LastError = b.lastError.Error()
+	}
+
+	return status
+}
+
+// transition must be called with mu held, listeners run outside of it
+func (b *Breaker) transition(to BreakerState) {
+	from := b.state
+	b.state = to
+
+	slog.Warn("redis circuit breaker", "from", from, "to", to, "failures", b.failures, "err", b.lastError)
+
+	if b.onChange != nil {
+		go b.onChange(from, to)
+	}
+}
+
+// failure reports whether err means Redis is unreachable or unhealthy. A
+// missing key or an error reply proves the server answered.
+func failure(err error) bool {
+	if err == nil || errors.Is(err, redis.Nil) || errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+
+	var reply redis.Error
+	if errors.As(err, &reply) {
+		// the server answers, but cannot serve yet or lost its primary
+		msg := reply.Error()
+		return strings.HasPrefix(msg, "LOADING") || strings.HasPrefix(msg, "READONLY") ||
+			strings.HasPrefix(msg, "MASTERDOWN")
+	}
+
+	return true
+}
+
+// breakerHook puts the breaker in front of every command of a client
+type breakerHook struct {
+	breaker *Breaker
+}
+
+func (h breakerHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h breakerHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if err := h.breaker.Allow(); err != nil {
+			cmd.SetErr(err)
+			return err
+		}
+
+		err := next(ctx, cmd)
+		h.breaker.Record(err)
+
+		return err
+	}
+}
+
+func (h breakerHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if err := h.breaker.Allow(); err != nil {
+			for _, cmd := range cmds {
+				cmd.SetErr(err)
+			}
+			return err
+		}
+
+		err := next(ctx, cmds)
+		h.breaker.Record(err)
+
+		return err
+	}
+}
@@ -0,0 +1,116 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var errDial = errors.New("dial tcp: connection refused")
+
+func TestBreaker(t *testing.T) {
+	type step struct {
+		advance   time.Duration
+		err       error // outcome of the command, when it is allowed
+		wantAllow bool
+		wantState BreakerState
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "opens after consecutive failures",
+			steps: []step{
+				{err: errDial, wantAllow: true, wantState: BreakerClosed},
+				{err: errDial, wantAllow: true, wantState: BreakerClosed},
+				{err: errDial, wantAllow: true, wantState: BreakerOpen},
+				{wantAllow: false, wantState: BreakerOpen},
+			},
+		},
+		{
+			name: "a success resets the count",
+			steps: []step{
+				{err: errDial, wantAllow: true, wantState: BreakerClosed},
+				{err: errDial, wantAllow: true, wantState: BreakerClosed},
+				{wantAllow: true, wantState: BreakerClosed},
+				{err: errDial, wantAllow: true, wantState: BreakerClosed},
+			},
+		},
+		{
+			name: "missing keys and error replies are not failures",
+			steps: []step{
+				{err: redis.Nil, wantAllow: true, wantState: BreakerClosed},
+				{err: errDial, wantAllow: true, wantState: BreakerClosed},
+				{err: errDial, wantAllow: true, wantState: BreakerClosed},
+				{err: redis.Nil, wantAllow: true, wantState: BreakerClosed},
+				{err: context.Canceled, wantAllow: true, wantState: BreakerClosed},
+			},
+		},
+		{
+			name: "a successful probe closes it",
+			steps: []step{
+				{err: errDial, wantAllow: true}, {err: errDial, wantAllow: true},
+				{err: errDial, wantAllow: true, wantState: BreakerOpen},
+				{advance: time.Second, wantAllow: false, wantState: BreakerOpen},
+				{advance: 5 * time.Second, wantAllow: true, wantState: BreakerClosed},
+			},
+		},
+		{
+			name: "a failed probe opens it again",
+			steps: []step{
+				{err: errDial, wantAllow: true}, {err: errDial, wantAllow: true},
+				{err: errDial, wantAllow: true, wantState: BreakerOpen},
+				{advance: 5 * time.Second, err: errDial, wantAllow: true, wantState: BreakerOpen},
+				{advance: time.Second, wantAllow: false, wantState: BreakerOpen},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now()
+			b := NewBreaker(BreakerOptions{Threshold: 3, Cooldown: 5 * time.Second})
+			b.now = func() time.Time { return now }
+
+			for i, s := range tt.steps {
+				now = now.Add(s.advance)
+
+				allowed := b.Allow() == nil
+				if allowed != s.wantAllow {
+					t.Fatalf("step %d: allowed = %v, want %v", i, allowed, s.wantAllow)
+				}
+				if allowed {
+					b.Record(s.err)
+				}
+
+				if s.wantState != "" && b.State() != s.wantState {
+					t.Fatalf("step %d: state = %s, want %s", i, b.State(), s.wantState)
+				}
+			}
+		})
+	}
+}
+
+func TestBreakerSingleProbe(t *testing.T) {
+	now := time.Now()
+	b := NewBreaker(BreakerOptions{Threshold: 1, Cooldown: time.Second})
+	b.now = func() time.Time { return now }
+
+	b.Trip(errDial)
+	now = now.Add(time.Second)
+
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe not allowed: %v", err)
+	}
+	if b.State() != BreakerHalfOpen {
+		t.Errorf("state = %s, want half_open", b.State())
+	}
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("second command during the probe: err = %v, want ErrCircuitOpen", err)
+	}
+}
@@ -0,0 +1,139 @@
+package database
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type replica struct {
+	pool      *pgxpool.Pool
+	healthy   atomic.Bool
+	lag       atomic.Int64 // nanoseconds
+	lagBytes  atomic.Int64
+	checkedAt atomic.Int64 // unix nanoseconds
+}
+
+// Cluster routes queries between the primary and its replicas. Used as a
+// query executor it always talks to the primary and marks the request
+// session as written; Reader picks a replica for reads.
+type Cluster struct {
+	primary  *pgxpool.Pool
+	replicas []*replica
+	next     atomic.Uint64
+	limits   ReplicaLimits
+}
+
+// ConnectCluster connects the primary and every replica. A replica that is
+// down at startup does not fail the connect, it stays out of rotation until
+// MonitorReplicas finds it healthy.
+func ConnectCluster(ctx context.Context, primaryDSN string, replicaDSNs []string, maxConns, minConns int32, limits ReplicaLimits) (*Cluster, error) {
+	primary, err := Connect(ctx, primaryDSN, maxConns, minConns)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Cluster{primary: primary, limits: limits}
+
+	for _, dsn := range replicaDSNs {
+		poolConfig, err := pgxpool.ParseConfig(dsn)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+
+		poolConfig.MaxConns = maxConns
+		poolConfig.MinConns = minConns
+		poolConfig.MaxConnLifetime = time.Hour
+		poolConfig.MaxConnIdleTime = 10 * time.Minute
+		poolConfig.ConnConfig.Tracer = queryTracer{}
+
+		pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+
+		c.replicas = append(c.replicas, &replica{pool: pool})
+	}
+
+	c.checkReplicas(ctx)
+
+	return c, nil
+}
+
+func (c *Cluster) Primary() *pgxpool.Pool {
+	return c.primary
+}
+
+// Pools returns every pool by name, "primary" and "replica:<host>"
+func (c *Cluster) Pools() map[string]*pgxpool.Pool {
+	pools := map[string]*pgxpool.Pool{"primary": c.primary}
+	for _, r := range c.replicas {
+		pools["replica:"+r.pool.Config().ConnConfig.Host] = r.pool
+	}
+
+	return pools
+}
+
+// Reader returns the pool reads should go to: the primary after a write in
+// the current session, otherwise the next healthy replica within the lag
+// limits, falling back to the primary when there is none. A staleness set
+// with WithMaxStaleness replaces the cluster wide lag limit.
+func (c *Cluster) Reader(ctx context.Context) *pgxpool.Pool {
+	if len(c.replicas) == 0 || ReadsPrimary(ctx) {
+		return c.primary
+	}
+
+	limits := c.limits
+	if staleness, ok := maxStaleness(ctx); ok {
+		limits.MaxLag = staleness
+	}
+
+	start := c.next.Add(1)
+	for i := range c.replicas {
+		r := c.replicas[(int(start)+i)%len(c.replicas)]
+		if r.usable(limits) {
+			return r.pool
+		}
+	}
+
+	return c.primary
+}
+
+func (c *Cluster) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	markWrite(ctx)
+	return c.primary.Exec(ctx, sql, args...)
+}
+
+func (c *Cluster) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	markWrite(ctx)
+	return c.primary.Query(ctx, sql, args...)
+}
+
+func (c *Cluster) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	markWrite(ctx)
+	return c.primary.QueryRow(ctx, sql, args...)
+}
+
+func (c *Cluster) Begin(ctx context.Context) (pgx.Tx, error) {
+	markWrite(ctx)
+	return c.primary.Begin(ctx)
+}
+
+func (c *Cluster) BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error) {
+	markWrite(ctx)
+	return c.primary.BeginTx(ctx, opts)
+}
+
+func (c *Cluster) Close() {
+	for _, r := range c.replicas {
+		r.pool.Close()
+	}
+
+	c.primary.Close()
+}
@@ -0,0 +1,136 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func newTestReplica(healthy bool, lag time.Duration) *replica {
+	r := &replica{pool: new(pgxpool.Pool)}
+	r.healthy.Store(healthy)
+	r.lag.Store(int64(lag))
+
+	return r
+}
+
+func TestClusterReader(t *testing.T) {
+	primary := new(pgxpool.Pool)
+
+	healthy := newTestReplica(true, 0)
+	down := newTestReplica(false, 0)
+	lagging := newTestReplica(true, time.Minute)
+
+	tests := []struct {
+		name     string
+		replicas []*replica
+		written  bool
+		want     *pgxpool.Pool
+	}{
+		{"no replicas", nil, false, primary},
+		{"healthy replica", []*replica{healthy}, false, healthy.pool},
+		{"read after write", []*replica{healthy}, true, primary},
+		{"unhealthy replica", []*replica{down}, false, primary},
+		{"lagging replica", []*replica{lagging}, false, primary},
+		{"skips bad replicas", []*replica{down, lagging, healthy}, false, healthy.pool},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Cluster{primary: primary, replicas: tt.replicas, limits: ReplicaLimits{MaxLag: 5 * time.Second}}
+
+			ctx := WithSession(context.Background())
+			if tt.written {
+				markWrite(ctx)
+			}
+
+			if got := c.Reader(ctx); got != tt.want {
+				t.Errorf("Reader() = %p, want %p", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClusterReaderRoundRobin(t *testing.T) {
+	a, b := newTestReplica(true, 0), newTestReplica(true, 0)
+	c := &Cluster{primary: new(pgxpool.Pool), replicas: []*replica{a, b}}
+
+	seen := map[*pgxpool.Pool]int{}
+	for range 4 {
+		seen[c.Reader(context.Background())]++
+	}
+
+	if seen[a.pool] != 2 || seen[b.pool] != 2 {
+		t.Errorf("reads per replica = %d/%d, want 2/2", seen[a.pool], seen[b.pool])
+	}
+}
+
+func TestSessionScope(t *testing.T) {
+	outside := context.Background()
+	markWrite(outside)
+	if wroteInSession(outside) {
+		t.Error("write without session must not be sticky")
+	}
+
+	first, second := WithSession(outside), WithSession(outside)
+	markWrite(first)
+
+	if !wroteInSession(first) || wroteInSession(second) {
+		t.Error("stickiness must be limited to the session that wrote")
+	}
+}
+
+func TestClusterReaderStaleness(t *testing.T) {
+	primary := new(pgxpool.Pool)
+	stale := newTestReplica(true, 10*time.Second)
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want *pgxpool.Pool
+	}{
+		{"cluster limit", context.Background(), primary},
+		{"looser staleness", WithMaxStaleness(context.Background(), time.Minute), stale.pool},
+		{"tighter staleness", WithMaxStaleness(context.Background(), time.Second), primary},
+		{"fresh read", WithMaxStaleness(context.Background(), 0), primary},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Cluster{primary: primary, replicas: []*replica{stale}, limits: ReplicaLimits{MaxLag: 5 * time.Second}}
+
+			if got := c.Reader(tt.ctx); got != tt.want {
+				t.Errorf("Reader() = %p, want %p", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClusterStatus(t *testing.T) {
+	behind := newTestReplica(true, 0)
+	behind.lagBytes.Store(1 << 20)
+
+	tests := []struct {
+		name     string
+		replicas []*replica
+		want     string
+	}{
+		{"no replicas", nil, StatusOK},
+		{"healthy", []*replica{newTestReplica(true, time.Second)}, StatusOK},
+		{"down", []*replica{newTestReplica(true, 0), newTestReplica(false, 0)}, StatusDegraded},
+		{"lagging", []*replica{newTestReplica(true, time.Minute)}, StatusDegraded},
+		{"lagging bytes", []*replica{behind}, StatusDegraded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Cluster{replicas: tt.replicas, limits: ReplicaLimits{MaxLag: 5 * time.Second, MaxLagBytes: 1 << 16}}
+
+			if got := c.Status(); got != tt.want {
+				t.Errorf("Status() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -18,6 +18,7 @@ func Connect(ctx context.Context, dsn string, maxConns, minConns int32) (*pgxpoo
 	poolConfig.MinConns = minConns
 	poolConfig.MaxConnLifetime = time.Hour
 	poolConfig.MaxConnIdleTime = 10 * time.Minute
+	poolConfig.ConnConfig.Tracer = queryTracer{}
 
 	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
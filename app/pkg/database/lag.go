@@ -0,0 +1,185 @@
+package database
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+)
+
+// primaryLSNQuery reads the WAL position replicas are compared against
+const primaryLSNQuery = `SELECT pg_current_wal_lsn()::text`
+
+// replicaLagQuery measures how far a replica is behind: in bytes of WAL
+// against the primary position (or against what the replica received when
+// the primary is unknown) and in seconds since its last replayed
+// transaction. The seconds only count while there is WAL left to replay, an
+// idle replica is not stale however old its last transaction is.
+const replicaLagQuery = `SELECT
+	COALESCE(pg_wal_lsn_diff(COALESCE($1::text::pg_lsn, pg_last_wal_receive_lsn()), pg_last_wal_replay_lsn()), 0)::bigint,
+	COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)::float8`
+
+// ReplicaLimits bound how stale a replica may be before reads skip it, zero
+// disables a limit
+type ReplicaLimits struct {
+	MaxLag      time.Duration
+	MaxLagBytes int64
+}
+
+// ReplicaStatus is the last measurement of one replica
+type ReplicaStatus struct {
+	Host       string    `json:"host"`
+	Healthy    bool      `json:"healthy"`
+	Lagging    bool      `json:"lagging"`
+	LagSeconds float64   `json:"lag_seconds"`
+	LagBytes   int64     `json:"lag_bytes"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
+
+type stalenessKey struct{}
+
+// WithMaxStaleness sets how far behind the primary reads made with ctx may
+// be. Zero sends reads to the primary.
+func WithMaxStaleness(ctx context.Context, staleness time.Duration) context.Context {
+	return context.WithValue(ctx, stalenessKey{}, staleness)
+}
+
+func maxStaleness(ctx context.Context) (time.Duration, bool) {
+	staleness, ok := ctx.Value(stalenessKey{}).(time.Duration)
+	return staleness, ok
+}
+
+// ReadsPrimary reports whether reads made with ctx skip the replicas, after
+// a write in the session or with a max staleness of zero
+func ReadsPrimary(ctx context.Context) bool {
+	staleness, ok := maxStaleness(ctx)
+	return wroteInSession(ctx) || (ok && staleness <= 0)
+}
+
+func (r *replica) usable(limits ReplicaLimits) bool {
+	return r.healthy.Load() && !r.lagging(limits)
+}
+
+func (r *replica) lagging(limits ReplicaLimits) bool {
+	if limits.MaxLag > 0 && time.Duration(r.lag.Load()) > limits.MaxLag {
+		return true
+	}
+
+	return limits.MaxLagBytes > 0 && r.lagBytes.Load() > limits.MaxLagBytes
+}
+
+// Replicas reports the last measurement of every replica
+func (c *Cluster) Replicas() []ReplicaStatus {
+	statuses := make([]ReplicaStatus, 0, len(c.replicas))
+	for _, r := range c.replicas {
+		status := ReplicaStatus{
+			Host:       r.pool.Config().ConnConfig.Host,
+			Healthy:    r.healthy.Load(),
+			Lagging:    r.lagging(c.limits),
+			LagSeconds: time.Duration(r.lag.Load()).Seconds(),
+			LagBytes:   r.lagBytes.Load(),
+		}
+		if at := r.checkedAt.Load(); at != 0 {
+			status.CheckedAt = time.Unix(0, at).UTC()
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses
+}
+
+// Status is degraded while any replica is down or lagging past the limits.
+// Reads still work then, they fall back to the primary.
+func (c *Cluster) Status() string {
+	for _, r := range c.replicas {
+		if !r.usable(c.limits) {
+			return StatusDegraded
+		}
+	}
+
+	return StatusOK
+}
+
+// MonitorReplicas measures health and lag of every replica each interval
+// until ctx is done
+func (c *Cluster) MonitorReplicas(ctx context.Context, interval time.Duration) {
+	if len(c.replicas) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			c.checkReplicas(ctx)
+		}
+	}
+}
+
+func (c *Cluster) checkReplicas(ctx context.Context) {
+	if len(c.replicas) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	// without the primary position replicas are measured against the WAL
+	// they received, which misses lag in shipping it
+	var primaryLSN *string
+	if err := c.primary.QueryRow(ctx, primaryLSNQuery).Scan(&primaryLSN); err != nil {
+		slog.Warn("read primary wal position", "err", err)
+	}
+
+	for _, r := range c.replicas {
+		c.check(ctx, r, primaryLSN)
+	}
+}
+
+func (c *Cluster) check(ctx context.Context, r *replica, primaryLSN *string) {
+	host := r.pool.Config().ConnConfig.Host
+
+	var (
+		lagBytes int64
+		seconds  float64
+	)
+	err := r.pool.QueryRow(ctx, replicaLagQuery, primaryLSN).Scan(&lagBytes, &seconds)
+	r.checkedAt.Store(time.Now().UnixNano())
+
+	if err != nil {
+		if r.healthy.Swap(false) {
+			slog.Warn("replica unhealthy, reading from primary", "host", host, "err", err)
+		}
+		return
+	}
+
+	lag := time.Duration(seconds * float64(time.Second))
+	if lagBytes <= 0 {
+		lag, lagBytes = 0, 0
+	}
+
+	wasLagging := r.lagging(c.limits)
+	r.lag.Store(int64(lag))
+	r.lagBytes.Store(lagBytes)
+
+	if !r.healthy.Swap(true) {
+		slog.Info("replica healthy", "host", host, "lag", lag, "lag_bytes", lagBytes)
+	}
+
+	switch lagging := r.lagging(c.limits); {
+	case lagging && !wasLagging:
+		slog.Warn("replica lagging, reading from primary", "host", host, "lag", lag, "lag_bytes", lagBytes)
+	case !lagging && wasLagging:
+		slog.Info("replica caught up", "host", host, "lag", lag, "lag_bytes", lagBytes)
+	}
+}
@@ -2,7 +2,6 @@ package database
 
 import (
 	"context"
-	"log/slog"
 	"sync"
 	"time"
 
@@ -10,11 +9,18 @@ import (
 )
 
 var (
-	redisClient *redis.Client
-	redisMu     sync.RWMutex
+	redisClient  *redis.Client
+	redisBreaker *Breaker
+	redisMu      sync.RWMutex
+
+	recoveredMu sync.Mutex
+	recovered   []func(ctx context.Context)
 )
 
-func ConnectRedis(ctx context.Context, addr string) error {
+// ConnectRedis creates the client behind a circuit breaker. An unreachable
+// Redis is returned as error, but the client is kept with the breaker open,
+// so the caller can start degraded and MonitorRedis picks it up later.
+func ConnectRedis(ctx context.Context, addr string, opts BreakerOptions) error {
 	redisMu.Lock()
 	defer redisMu.Unlock()
 
@@ -27,7 +33,22 @@ func ConnectRedis(ctx context.Context, addr string) error {
 		MinIdleConns: 5,
 	})
 
-	return redisClient.Ping(ctx).Err()
+	redisBreaker = NewBreaker(opts)
+	redisBreaker.onChange = func(from, to BreakerState) {
+		if to == BreakerClosed {
+			runRecovered()
+		}
+	}
+	// hooks run in the order added, rejected commands get a span too
+	redisClient.AddHook(tracingHook{})
+	redisClient.AddHook(breakerHook{breaker: redisBreaker})
+
+	err := redisClient.Ping(ctx).Err()
+	if err != nil {
+		redisBreaker.Trip(err)
+	}
+
+	return err
 }
 
 func Redis() *redis.Client {
@@ -37,30 +58,80 @@ func Redis() *redis.Client {
 	return redisClient
 }
 
-func CloseRedis() error {
-	redisMu.Lock()
-	defer redisMu.Unlock()
+// RedisAvailable reports whether Redis is connected and its breaker closed.
+// Caches are bypassed while it is not.
+func RedisAvailable() bool {
+	redisMu.RLock()
+	defer redisMu.RUnlock()
 
-	if redisClient != nil {
-		return redisClient.Close()
+	return redisClient != nil && redisBreaker.State() == BreakerClosed
+}
+
+// RedisStatus returns the state of the breaker in front of Redis
+func RedisStatus() BreakerStatus {
+	redisMu.RLock()
+	defer redisMu.RUnlock()
+
+	if redisBreaker == nil {
+		return BreakerStatus{State: BreakerOpen, LastError: "not connected"}
 	}
 
-	return nil
+	return redisBreaker.Status()
 }
 
-func StartRedisHealthCheck(ctx context.Context, interval time.Duration) {
+// OnRedisRecovered registers fn to run whenever the breaker closes again,
+// e.g. to replay cache invalidations that could not be sent meanwhile
+func OnRedisRecovered(fn func(ctx context.Context)) {
+	recoveredMu.Lock()
+	defer recoveredMu.Unlock()
+
+	recovered = append(recovered, fn)
+}
+
+func runRecovered() {
+	recoveredMu.Lock()
+	fns := append([]func(ctx context.Context){}, recovered...)
+	recoveredMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for _, fn := range fns {
+		fn(ctx)
+	}
+}
+
+// MonitorRedis pings Redis every interval while the breaker is not closed,
+// the ping is the half-open probe when there is no traffic
+func MonitorRedis(ctx context.Context, interval time.Duration) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for {
 		select {
-            case <-ctx.Done():
-                return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
 
-            case <-ticker.C:
-                if err := Redis().Ping(ctx).Err(); err != nil {
-                    slog.Error("redis health check failed", "err", err)
-                }
+		client := Redis()
+		if client == nil || RedisAvailable() {
+			continue
 		}
+
+		pingCtx, cancel := context.WithTimeout(ctx, interval)
+		client.Ping(pingCtx)
+		cancel()
+	}
+}
+
+func CloseRedis() error {
+	redisMu.Lock()
+	defer redisMu.Unlock()
+
+	if redisClient != nil {
+		return redisClient.Close()
 	}
-}
\ No newline at end of file
+
+	return nil
+}
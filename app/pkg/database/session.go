@@ -0,0 +1,31 @@
+package database
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type sessionKey struct{}
+
+// session tracks whether the current request already wrote to the primary
+type session struct {
+	wrote atomic.Bool
+}
+
+// WithSession starts a read-your-writes scope, typically one per request:
+// once something is written through the cluster, later reads in the same
+// scope go to the primary instead of a replica that may not have caught up
+func WithSession(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sessionKey{}, &session{})
+}
+
+func markWrite(ctx context.Context) {
+	if s, ok := ctx.Value(sessionKey{}).(*session); ok {
+		s.wrote.Store(true)
+	}
+}
+
+func wroteInSession(ctx context.Context) bool {
+	s, ok := ctx.Value(sessionKey{}).(*session)
+	return ok && s.wrote.Load()
+}
@@ -1,7 +1,9 @@
 package repository
 
 import (
+	"context"
 	"errors"
+	"strings"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -14,11 +16,24 @@ import (
 )
 
 
+// Querier is implemented by both *pgxpool.Pool and pgx.Tx, so repositories
+// can run on the pool or inside a transaction
+type Querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// readRouter is implemented by database.Cluster, which sends reads to replicas
+type readRouter interface {
+	Reader(ctx context.Context) *pgxpool.Pool
+}
+
 type BaseRepository struct {
-	db *pgxpool.Pool
+	db Querier
 }
 
-func NewBaseRepository(db *pgxpool.Pool) *BaseRepository {
+func NewBaseRepository(db Querier) *BaseRepository {
 	return &BaseRepository{db: db}
 }
 
@@ -26,10 +41,31 @@ func (r *BaseRepository) Redis() *redis.Client {
 	return database.Redis()
 }
 
-func (r *BaseRepository) DB() *pgxpool.Pool {
+// DB returns the connection for writes and for reads that must see them.
+// Queries run in the transaction of their ctx when there is one, see WithTx.
+func (r *BaseRepository) DB() Querier {
+	return txQuerier{db: r.db}
+}
+
+// Reader returns the connection for reads that may be served by a replica.
+// Inside a transaction it is the transaction itself.
+func (r *BaseRepository) Reader(ctx context.Context) Querier {
+	if uow := txFromContext(ctx); uow != nil {
+		return uow.tx
+	}
+
+	if router, ok := r.db.(readRouter); ok {
+		return router.Reader(ctx)
+	}
+
 	return r.db
 }
 
+// AfterCommit defers a cache write until the transaction in ctx commits
+func (r *BaseRepository) AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	AfterCommit(ctx, fn)
+}
+
 func (r *BaseRepository) HandleError(err error) error {
 	if err == nil {
 		return nil
@@ -39,18 +75,39 @@ func (r *BaseRepository) HandleError(err error) error {
 		return domain.ErrNotFound
 	}
 
-	if pgErr, ok := err.(*pgconn.PgError); ok {
+	// the Postgres message names tables and constraints, it stays internal
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
 		switch pgErr.Code {
 		case "23505": // unique_violation
-			return domain.ErrAlreadyExists
+			return domain.ErrAlreadyExists.Wrap(err)
 		case "23503": // foreign_key_violation
-			return domain.ErrForeignKey
+			if column := foreignKeyColumn(pgErr); column != "" {
+				return domain.ErrForeignKey.Explain("%s", column).Wrap(err)
+			}
+			return domain.ErrForeignKey.Wrap(err)
 		case "23502": // not_null_violation
-			return domain.ErrInvalidInput
+			return domain.ErrInvalidInput.Wrap(err)
 		case "23514": // check_violation
-			return domain.ErrInvalidInput
+			return domain.ErrInvalidInput.Wrap(err)
 		}
 	}
 
 	return err
 }
+
+// foreignKeyColumn is the column of a violated foreign key with the default
+// <table>_<column>_fkey name, e.g. client_id
+func foreignKeyColumn(pgErr *pgconn.PgError) string {
+	name, ok := strings.CutPrefix(pgErr.ConstraintName, pgErr.TableName+"_")
+	if !ok {
+		return ""
+	}
+
+	column, ok := strings.CutSuffix(name, "_fkey")
+	if !ok {
+		return ""
+	}
+
+	return column
+}
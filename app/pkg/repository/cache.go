@@ -0,0 +1,528 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+
+	"api/internal/domain"
+	"api/pkg/database"
+)
+
+// CacheInvalidationChannel carries the ids written by one instance, so the
+// others drop them from their local cache
+const CacheInvalidationChannel = "cache:invalidate"
+
+// notFoundMarker is cached for ids that do not exist
+var notFoundMarker = []byte("!notfound")
+
+// tombstone replaces an invalidated entry for tombstoneTTL. Fills only set
+// absent keys, so a load that read the row before the write cannot put the
+// old row back; the tombstone reads as a miss.
+var tombstone = []byte("!invalidated")
+
+const tombstoneTTL = 10 * time.Second
+
+const generationSlots = 256
+
+// errTombstone is decoded from a tombstone
+var errTombstone = errors.New("cache entry invalidated")
+
+// CacheStats is told about every cache lookup, see SetCacheStats
+type CacheStats interface {
+	Hit(cache string)
+	Miss(cache string)
+}
+
+var cacheStats atomic.Pointer[CacheStats]
+
+// SetCacheStats sets where hits and misses of every cache are counted
+func SetCacheStats(stats CacheStats) {
+	cacheStats.Store(&stats)
+}
+
+func countLookup(cache string, hit bool) {
+	stats := cacheStats.Load()
+	if stats == nil {
+		return
+	}
+
+	if hit {
+		(*stats).Hit(cache)
+	} else {
+		(*stats).Miss(cache)
+	}
+}
+
+type CacheOptions struct {
+	// Name prefixes the keys and routes invalidations, e.g. "credits"
+	Name string
+	// TTL bounds how long an entry outlives a write that failed to
+	// invalidate it
+	TTL time.Duration
+	// NegativeTTL is how long a missing id is remembered, zero disables it
+	NegativeTTL time.Duration
+	// LocalTTL keeps entries in process memory too, zero disables it
+	LocalTTL time.Duration
+}
+
+// Cache is a read-through cache of entities by id in Redis, with an
+// optional in-process layer in front. Writers invalidate ids, readers fill
+// them again; concurrent misses of the same id load it once.
+type Cache[T any] struct {
+	opts   CacheOptions
+	prefix string
+	group  singleflight.Group
+
+	mu    sync.Mutex
+	local map[int]localEntry
+	// generations count invalidations by id modulo their length. A load only
+	// fills the cache when no invalidation of its slot happened since it
+	// started, an id sharing the slot costs a skipped fill at most.
+	generations [generationSlots]uint64
+	// pending are ids invalidated while Redis was down
+	pending map[int]struct{}
+}
+
+type localEntry struct {
+	data    []byte
+	expires time.Time
+}
+
+var (
+	cachesMu sync.RWMutex
+	caches   = map[string]invalidator{}
+)
+
+type invalidator interface {
+	dropLocal(ids ...int)
+}
+
+// NewCache creates the cache of one entity type. Keys carry a hash of the
+// JSON shape of T, so entries written by an older build with another shape
+// are never read back.
+func NewCache[T any](opts CacheOptions) *Cache[T] {
+	c := &Cache[T]{
+		opts:   opts,
+		prefix: "cache:" + opts.Name + ":" + shapeVersion(reflect.TypeFor[T]()) + ":",
+		local:  make(map[int]localEntry),
+
+		pending: make(map[int]struct{}),
+	}
+
+	database.OnRedisRecovered(c.recover)
+
+	cachesMu.Lock()
+	caches[opts.Name] = c
+	cachesMu.Unlock()
+
+	return c
+}
+
+func (c *Cache[T]) key(id int) string {
+	return c.prefix + strconv.Itoa(id)
+}
+
+// cacheBypassed reports whether the Redis breaker is open. Caches are
+// skipped then, local entries included, as invalidations from other
+// instances no longer arrive.
+func cacheBypassed() bool {
+	return database.Redis() != nil && !database.RedisAvailable()
+}
+
+// Get returns the entity from the cache or loads and caches it. Inside a
+// transaction the cache is bypassed, the transaction reads its own snapshot.
+// A load failing with domain.ErrNotFound is cached as a miss. Loads that fill
+// the cache read from the primary, a lagging replica could otherwise cache
+// again what was just invalidated.
+func (c *Cache[T]) Get(ctx context.Context, id int, load func(ctx context.Context) (T, error)) (T, error) {
+	if InTx(ctx) || cacheBypassed() {
+		return load(ctx)
+	}
+
+	if data, ok := c.lookup(ctx, id); ok {
+		entity, err := c.decode(data)
+		// an entry that no longer decodes counts as a miss
+		if err == nil || errors.Is(err, domain.ErrNotFound) {
+			countLookup(c.opts.Name, true)
+			return entity, err
+		}
+	}
+
+	countLookup(c.opts.Name, false)
+
+	v, err, _ := c.group.Do(strconv.Itoa(id), func() (any, error) {
+		generation := c.generation(id)
+
+		entity, err := load(database.WithMaxStaleness(ctx, 0))
+		if errors.Is(err, domain.ErrNotFound) {
+			c.store(ctx, id, generation, notFoundMarker, c.opts.NegativeTTL)
+		}
+		if err != nil {
+			return entity, err
+		}
+
+		if data, err := json.Marshal(entity); err == nil {
+			c.store(ctx, id, generation, data, c.opts.TTL)
+		}
+
+		return entity, nil
+	})
+
+	entity, _ := v.(T)
+	return entity, err
+}
+
+// GetMany returns the entities of ids found in the cache or loaded with
+// loadMany, keyed by id. Ids that do not exist are left out. Like Get, it
+// loads from the primary unless the cache is bypassed.
+func (c *Cache[T]) GetMany(ctx context.Context, ids []int, idOf func(T) int, loadMany func(ctx context.Context, ids []int) ([]T, error)) (map[int]T, error) {
+	found := make(map[int]T, len(ids))
+	missing := make([]int, 0)
+
+	bypass := InTx(ctx) || cacheBypassed()
+
+	if !bypass {
+		for id, data := range c.lookupMany(ctx, ids) {
+			if entity, err := c.decode(data); err == nil {
+				found[id] = entity
+			}
+		}
+	}
+
+	for _, id := range ids {
+		_, ok := found[id]
+		if !ok {
+			missing = append(missing, id)
+		}
+		if !bypass {
+			countLookup(c.opts.Name, ok)
+		}
+	}
+
+	if len(missing) == 0 {
+		return found, nil
+	}
+
+	loadCtx := ctx
+	if !bypass {
+		loadCtx = database.WithMaxStaleness(ctx, 0)
+	}
+
+	generations := make(map[int]uint64, len(missing))
+	for _, id := range missing {
+		generations[id] = c.generation(id)
+	}
+
+	loaded, err := loadMany(loadCtx, missing)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entity := range loaded {
+		id := idOf(entity)
+		found[id] = entity
+
+		if bypass {
+			continue
+		}
+		if data, err := json.Marshal(entity); err == nil {
+			c.store(ctx, id, generations[id], data, c.opts.TTL)
+		}
+	}
+
+	return found, nil
+}
+
+func (c *Cache[T]) decode(data []byte) (T, error) {
+	var entity T
+	switch string(data) {
+	case string(notFoundMarker):
+		return entity, domain.ErrNotFound
+	case string(tombstone):
+		return entity, errTombstone
+	}
+
+	err := json.Unmarshal(data, &entity)
+	return entity, err
+}
+
+func (c *Cache[T]) lookup(ctx context.Context, id int) ([]byte, bool) {
+	if c.opts.LocalTTL > 0 {
+		c.mu.Lock()
+		entry, ok := c.local[id]
+		c.mu.Unlock()
+
+		if ok && time.Now().Before(entry.expires) {
+			return entry.data, true
+		}
+	}
+
+	client := database.Redis()
+	if client == nil {
+		return nil, false
+	}
+
+	data, err := client.Get(ctx, c.key(id)).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			slog.Warn("cache read failed", "cache", c.opts.Name, "id", id, "err", err)
+		}
+		return nil, false
+	}
+
+	c.keepLocal(id, data)
+
+	return data, true
+}
+
+// lookupMany is lookup for several ids with a single MGET
+func (c *Cache[T]) lookupMany(ctx context.Context, ids []int) map[int][]byte {
+	found := make(map[int][]byte, len(ids))
+	remote := make([]int, 0, len(ids))
+
+	now := time.Now()
+	c.mu.Lock()
+	for _, id := range ids {
+		if entry, ok := c.local[id]; ok && now.Before(entry.expires) {
+			found[id] = entry.data
+		} else {
+			remote = append(remote, id)
+		}
+	}
+	c.mu.Unlock()
+
+	client := database.Redis()
+	if client == nil || len(remote) == 0 {
+		return found
+	}
+
+	keys := make([]string, len(remote))
+	for i, id := range remote {
+		keys[i] = c.key(id)
+	}
+
+	values, err := client.MGet(ctx, keys...).Result()
+	if err != nil {
+		slog.Warn("cache read failed", "cache", c.opts.Name, "ids", remote, "err", err)
+		return found
+	}
+
+	for i, value := range values {
+		if data, ok := value.(string); ok {
+			found[remote[i]] = []byte(data)
+			c.keepLocal(remote[i], []byte(data))
+		}
+	}
+
+	return found
+}
+
+// store fills the entry of id loaded at generation. It is skipped when id
+// was invalidated meanwhile, and the Redis key is only set while absent, so
+// a tombstone left by another instance wins as well.
+func (c *Cache[T]) store(ctx context.Context, id int, generation uint64, data []byte, ttl time.Duration) {
+	if ttl <= 0 || c.generation(id) != generation {
+		return
+	}
+
+	if client := database.Redis(); client != nil {
+		stored, err := client.SetNX(ctx, c.key(id), data, ttl).Result()
+		if err != nil {
+			slog.Warn("cache write failed", "cache", c.opts.Name, "id", id, "err", err)
+		}
+		if !stored {
+			return
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.generations[slot(id)] == generation {
+		c.keepLocked(id, data)
+	}
+}
+
+func (c *Cache[T]) generation(id int) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.generations[slot(id)]
+}
+
+func slot(id int) int {
+	return int(uint(id) % generationSlots)
+}
+
+func (c *Cache[T]) keepLocal(id int, data []byte) {
+	c.mu.Lock()
+	c.keepLocked(id, data)
+	c.mu.Unlock()
+}
+
+func (c *Cache[T]) keepLocked(id int, data []byte) {
+	if c.opts.LocalTTL <= 0 || string(data) == string(tombstone) {
+		return
+	}
+
+	c.local[id] = localEntry{data: data, expires: time.Now().Add(c.opts.LocalTTL)}
+}
+
+func (c *Cache[T]) dropLocal(ids ...int) {
+	c.mu.Lock()
+	for _, id := range ids {
+		delete(c.local, id)
+		c.generations[slot(id)]++
+	}
+	c.mu.Unlock()
+}
+
+// Invalidate replaces ids with tombstones once the transaction in ctx
+// commits, right away outside a transaction, and tells the other instances
+// to drop them. While
+// Redis is down the ids are kept and invalidated once it is back.
+func (c *Cache[T]) Invalidate(ctx context.Context, ids ...int) {
+	AfterCommit(ctx, func(ctx context.Context) {
+		c.dropLocal(ids...)
+
+		client := database.Redis()
+		if client == nil {
+			return
+		}
+
+		if cacheBypassed() || !c.invalidate(ctx, client, ids) {
+			c.mu.Lock()
+			for _, id := range ids {
+				c.pending[id] = struct{}{}
+			}
+			c.mu.Unlock()
+		}
+	})
+}
+
+func (c *Cache[T]) invalidate(ctx context.Context, client *redis.Client, ids []int) bool {
+	pipe := client.Pipeline()
+	for _, id := range ids {
+		pipe.Set(ctx, c.key(id), tombstone, tombstoneTTL)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		slog.Warn("cache invalidation failed", "cache", c.opts.Name, "ids", ids, "err", err)
+		return false
+	}
+
+	msg, _ := json.Marshal(invalidation{Cache: c.opts.Name, IDs: ids})
+	if err := client.Publish(ctx, CacheInvalidationChannel, msg).Err(); err != nil {
+		slog.Warn("cache invalidation broadcast failed", "cache", c.opts.Name, "err", err)
+		return false
+	}
+
+	return true
+}
+
+// recover runs when Redis is back: local entries may have missed
+// invalidations from other instances, and ids written meanwhile are
+// invalidated now
+func (c *Cache[T]) recover(ctx context.Context) {
+	c.mu.Lock()
+	clear(c.local)
+	ids := make([]int, 0, len(c.pending))
+	for id := range c.pending {
+		ids = append(ids, id)
+	}
+	clear(c.pending)
+	c.mu.Unlock()
+
+	if len(ids) == 0 {
+		return
+	}
+
+	slog.Info("replaying cache invalidations", "cache", c.opts.Name, "ids", len(ids))
+
+	if client := database.Redis(); client == nil || !c.invalidate(ctx, client, ids) {
+		c.mu.Lock()
+		for _, id := range ids {
+			c.pending[id] = struct{}{}
+		}
+		c.mu.Unlock()
+	}
+}
+
+type invalidation struct {
+	Cache string `json:"cache"`
+	IDs   []int  `json:"ids"`
+}
+
+// SubscribeCacheInvalidations drops the local entries other instances
+// invalidate, until ctx is done
+func SubscribeCacheInvalidations(ctx context.Context, client *redis.Client) {
+	sub := client.Subscribe(ctx, CacheInvalidationChannel)
+	defer sub.Close()
+
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+
+			var inv invalidation
+			if err := json.Unmarshal([]byte(msg.Payload), &inv); err != nil {
+				slog.Warn("malformed cache invalidation", "payload", msg.Payload, "err", err)
+				continue
+			}
+
+			cachesMu.RLock()
+			cache, ok := caches[inv.Cache]
+			cachesMu.RUnlock()
+
+			if ok {
+				cache.dropLocal(inv.IDs...)
+			}
+		}
+	}
+}
+
+// shapeVersion hashes field names, types and json tags of t
+func shapeVersion(t reflect.Type) string {
+	h := sha256.New()
+	writeShape(h, t, map[reflect.Type]bool{})
+
+	return hex.EncodeToString(h.Sum(nil))[:8]
+}
+
+func writeShape(h interface{ Write([]byte) (int, error) }, t reflect.Type, seen map[reflect.Type]bool) {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+		fmt.Fprintf(h, "%s:", t.Kind())
+		t = t.Elem()
+	}
+
+	fmt.Fprintf(h, "%s;", t.String())
+	if t.Kind() != reflect.Struct || seen[t] {
+		return
+	}
+	seen[t] = true
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		fmt.Fprintf(h, "%s %s;", f.Name, f.Tag.Get("json"))
+		writeShape(h, f.Type, seen)
+	}
+}
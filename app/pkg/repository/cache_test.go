@@ -0,0 +1,294 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"api/internal/domain"
+	"api/pkg/database"
+)
+
+type cachedItem struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// the tests run without Redis, so only the local layer holds entries
+func newTestCache(name string) *Cache[cachedItem] {
+	return NewCache[cachedItem](CacheOptions{
+		Name:        name,
+		TTL:         time.Minute,
+		NegativeTTL: time.Minute,
+		LocalTTL:    time.Minute,
+	})
+}
+
+// loader counts its calls and answers with item or err
+func loader(calls *atomic.Int32, item cachedItem, err error) func(context.Context) (cachedItem, error) {
+	return func(ctx context.Context) (cachedItem, error) {
+		calls.Add(1)
+		return item, err
+	}
+}
+
+func TestCacheGet(t *testing.T) {
+	tests := []struct {
+		name      string
+		loadErr   error
+		inTx      bool
+		wantCalls int32
+		wantErr   error
+	}{
+		{name: "second read is a hit", wantCalls: 1},
+		{name: "missing ids are cached", loadErr: domain.ErrNotFound, wantCalls: 1, wantErr: domain.ErrNotFound},
+		{name: "other errors are not cached", loadErr: errors.New("boom"), wantCalls: 2, wantErr: errors.New("boom")},
+		{name: "transactions bypass the cache", inTx: true, wantCalls: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestCache("test-get")
+			var calls atomic.Int32
+			load := loader(&calls, cachedItem{ID: 1, Name: "a"}, tt.loadErr)
+
+			ctx := context.Background()
+			if tt.inTx {
+				ctx = context.WithValue(ctx, txKey{}, &unitOfWork{tx: &fakeTx{}})
+			}
+
+			for range 2 {
+				item, err := c.Get(ctx, 1, load)
+				if (err == nil) != (tt.wantErr == nil) || (err != nil && err.Error() != tt.wantErr.Error()) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				if err == nil && item.Name != "a" {
+					t.Errorf("item = %+v", item)
+				}
+			}
+
+			if calls.Load() != tt.wantCalls {
+				t.Errorf("loaded %d times, want %d", calls.Load(), tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestCacheInvalidate(t *testing.T) {
+	c := newTestCache("test-invalidate")
+	var calls atomic.Int32
+	load := loader(&calls, cachedItem{ID: 1}, nil)
+	ctx := context.Background()
+
+	c.Get(ctx, 1, load)
+	c.Invalidate(ctx, 1)
+	c.Get(ctx, 1, load)
+
+	if calls.Load() != 2 {
+		t.Errorf("loaded %d times, want 2", calls.Load())
+	}
+
+	t.Run("waits for the commit", func(t *testing.T) {
+		uow := &unitOfWork{tx: &fakeTx{}}
+		c.Invalidate(context.WithValue(ctx, txKey{}, uow), 1)
+
+		c.Get(ctx, 1, load)
+		if calls.Load() != 2 {
+			t.Errorf("entry dropped before commit")
+		}
+	})
+}
+
+func TestCacheSingleFlight(t *testing.T) {
+	c := newTestCache("test-singleflight")
+	var calls atomic.Int32
+	release := make(chan struct{})
+
+	load := func(ctx context.Context) (cachedItem, error) {
+		calls.Add(1)
+		<-release
+		return cachedItem{ID: 1}, nil
+	}
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Get(context.Background(), 1, load)
+		}()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("loaded %d times, want 1", calls.Load())
+	}
+}
+
+func TestShapeVersion(t *testing.T) {
+	type renamed struct {
+		ID   int    `json:"id"`
+		Name string `json:"full_name"`
+	}
+
+	item := shapeVersion(reflect.TypeFor[cachedItem]())
+	if item != shapeVersion(reflect.TypeFor[cachedItem]()) {
+		t.Error("shape version is not stable")
+	}
+	if item == shapeVersion(reflect.TypeFor[renamed]()) {
+		t.Error("a renamed json field kept the shape version")
+	}
+}
+
+func TestCacheGetMany(t *testing.T) {
+	c := newTestCache("test-getmany")
+	ctx := context.Background()
+	idOf := func(item cachedItem) int { return item.ID }
+
+	var loaded [][]int
+	loadMany := func(ctx context.Context, ids []int) ([]cachedItem, error) {
+		loaded = append(loaded, ids)
+
+		items := make([]cachedItem, 0, len(ids))
+		for _, id := range ids {
+			// 3 does not exist
+			if id != 3 {
+				items = append(items, cachedItem{ID: id})
+			}
+		}
+		return items, nil
+	}
+
+	c.Get(ctx, 1, func(ctx context.Context) (cachedItem, error) { return cachedItem{ID: 1}, nil })
+
+	found, err := c.GetMany(ctx, []int{1, 2, 3}, idOf, loadMany)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 2 || found[1].ID != 1 || found[2].ID != 2 {
+		t.Errorf("found = %+v", found)
+	}
+	if len(loaded) != 1 || !reflect.DeepEqual(loaded[0], []int{2, 3}) {
+		t.Errorf("loaded %v, want only the uncached ids [2 3]", loaded)
+	}
+
+	c.GetMany(ctx, []int{1, 2}, idOf, loadMany)
+	if len(loaded) != 1 {
+		t.Errorf("cached ids were loaded again: %v", loaded)
+	}
+}
+
+// replicaLoader answers like a lagging replica unless ctx reads from the
+// primary: the replica still has the row before the write, or no row at all
+func replicaLoader(primary cachedItem, stale cachedItem, staleErr error) func(context.Context) (cachedItem, error) {
+	return func(ctx context.Context) (cachedItem, error) {
+		if database.ReadsPrimary(ctx) {
+			return primary, nil
+		}
+		return stale, staleErr
+	}
+}
+
+func TestCacheFillsFromPrimary(t *testing.T) {
+	written := cachedItem{ID: 1, Name: "new"}
+	idOf := func(item cachedItem) int { return item.ID }
+
+	tests := []struct {
+		name     string
+		stale    cachedItem
+		staleErr error
+	}{
+		{name: "stale row", stale: cachedItem{ID: 1, Name: "old"}},
+		{name: "row not replicated yet", staleErr: domain.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestCache("test-primary")
+			ctx := context.Background()
+			load := replicaLoader(written, tt.stale, tt.staleErr)
+
+			c.Get(ctx, 1, func(ctx context.Context) (cachedItem, error) { return cachedItem{ID: 1, Name: "old"}, nil })
+			c.Invalidate(ctx, 1)
+
+			for range 2 {
+				item, err := c.Get(ctx, 1, load)
+				if err != nil || item != written {
+					t.Fatalf("Get() = %+v, %v, want %+v", item, err, written)
+				}
+			}
+
+			c.Invalidate(ctx, 1)
+			loadMany := func(ctx context.Context, ids []int) ([]cachedItem, error) {
+				item, err := load(ctx)
+				if errors.Is(err, domain.ErrNotFound) {
+					return nil, nil
+				}
+				return []cachedItem{item}, err
+			}
+
+			found, err := c.GetMany(ctx, []int{1}, idOf, loadMany)
+			if err != nil || found[1] != written {
+				t.Errorf("GetMany() = %+v, %v, want %+v", found, err, written)
+			}
+		})
+	}
+}
+
+// a write committing between the load and the fill must not leave the row
+// read before it cached
+func TestCacheFillAfterInvalidate(t *testing.T) {
+	idOf := func(item cachedItem) int { return item.ID }
+
+	tests := []struct {
+		name string
+		get  func(c *Cache[cachedItem], ctx context.Context, row func() cachedItem) (cachedItem, error)
+	}{
+		{"Get", func(c *Cache[cachedItem], ctx context.Context, row func() cachedItem) (cachedItem, error) {
+			return c.Get(ctx, 1, func(ctx context.Context) (cachedItem, error) { return row(), nil })
+		}},
+		{"GetMany", func(c *Cache[cachedItem], ctx context.Context, row func() cachedItem) (cachedItem, error) {
+			found, err := c.GetMany(ctx, []int{1}, idOf, func(ctx context.Context, ids []int) ([]cachedItem, error) {
+				return []cachedItem{row()}, nil
+			})
+			return found[1], err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestCache("test-fill-race")
+			ctx := context.Background()
+
+			// load the old row, then the writer commits and invalidates, then
+			// the fill runs
+			racing := func() cachedItem {
+				c.Invalidate(ctx, 1)
+				return cachedItem{ID: 1, Name: "old"}
+			}
+			if item, _ := tt.get(c, ctx, racing); item.Name != "old" {
+				t.Fatalf("first read = %+v", item)
+			}
+
+			item, err := tt.get(c, ctx, func() cachedItem { return cachedItem{ID: 1, Name: "new"} })
+			if err != nil || item.Name != "new" {
+				t.Errorf("read after the write = %+v, %v, want the new row", item, err)
+			}
+		})
+	}
+}
+
+func TestCacheDecodeTombstone(t *testing.T) {
+	c := newTestCache("test-tombstone")
+
+	if _, err := c.decode(tombstone); !errors.Is(err, errTombstone) {
+		t.Errorf("decode(tombstone) error = %v, want a miss", err)
+	}
+}
@@ -3,9 +3,13 @@ package repository
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
+
+	"api/internal/domain"
+	"api/pkg/database"
 )
 
 type ScanFunc[T any] func(row pgx.Row) (T, error)
@@ -15,7 +19,7 @@ type CRUD[T any] struct {
 	tableName string
 }
 
-func NewCRUD[T any](db *pgxpool.Pool, tableName string) *CRUD[T] {
+func NewCRUD[T any](db Querier, tableName string) *CRUD[T] {
 	return &CRUD[T]{
 		BaseRepository: NewBaseRepository(db),
 		tableName:      tableName,
@@ -27,7 +31,7 @@ func (c *CRUD[T]) GetByID(ctx context.Context, id int, scanFn ScanFunc[T]) (T, e
 	var zero T
 	query := fmt.Sprintf("SELECT * FROM %s WHERE id = $1", c.tableName)
 	
-	row := c.db.QueryRow(ctx, query, id)
+	row := c.Reader(ctx).QueryRow(ctx, query, id)
 	result, err := scanFn(row)
 	
 	if err != nil {
@@ -37,10 +41,35 @@ func (c *CRUD[T]) GetByID(ctx context.Context, id int, scanFn ScanFunc[T]) (T, e
 	return result, nil
 }
 
+// GetMany retrieves the records with the given IDs in no particular order,
+// missing IDs are skipped
+func (c *CRUD[T]) GetMany(ctx context.Context, ids []int, scanFn ScanFunc[T]) ([]T, error) {
+	query := fmt.Sprintf("SELECT * FROM %s WHERE id = ANY($1)", c.tableName)
+
+	rows, err := c.Reader(ctx).Query(ctx, query, ids)
+	if err != nil {
+		return nil, c.HandleError(err)
+	}
+
+	defer rows.Close()
+
+	items := make([]T, 0, len(ids))
+	for rows.Next() {
+		item, err := scanFn(rows)
+		if err != nil {
+			return nil, c.HandleError(err)
+		}
+
+		items = append(items, item)
+	}
+
+	return items, c.HandleError(rows.Err())
+}
+
 // Delete removes a record by ID
 func (c *CRUD[T]) Delete(ctx context.Context, id int) error {
 	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1", c.tableName)
-	result, err := c.db.Exec(ctx, query, id)
+	result, err := c.DB().Exec(ctx, query, id)
 	
 	if err != nil {
 		return c.HandleError(err)
@@ -53,6 +82,41 @@ func (c *CRUD[T]) Delete(ctx context.Context, id int) error {
 	return nil
 }
 
+// DeleteVersion removes a record by ID if it is still at the given version,
+// zero deletes whatever the version
+func (c *CRUD[T]) DeleteVersion(ctx context.Context, id, version int) error {
+	if version == 0 {
+		return c.Delete(ctx, id)
+	}
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1 AND version = $2", c.tableName)
+	result, err := c.DB().Exec(ctx, query, id, version)
+	if err != nil {
+		return c.HandleError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return c.Missed(ctx, id)
+	}
+
+	return nil
+}
+
+// Missed explains a versioned write that matched no row: the record is gone
+// or its version moved on
+func (c *CRUD[T]) Missed(ctx context.Context, id int) error {
+	exists, err := c.Exists(database.WithMaxStaleness(ctx, 0), id)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return domain.ErrNotFound
+	}
+
+	return domain.ErrVersionConflict
+}
+
 // Count returns the total number of records
 func (c *CRUD[T]) Count(ctx context.Context, whereClause string, args ...any) (int64, error) {
 	query := fmt.Sprintf("SELECT COUNT(*) FROM %s", c.tableName)
@@ -62,7 +126,7 @@ func (c *CRUD[T]) Count(ctx context.Context, whereClause string, args ...any) (i
 	
 	var count int64
 
-	err := c.db.QueryRow(ctx, query, args...).Scan(&count)
+	err := c.Reader(ctx).QueryRow(ctx, query, args...).Scan(&count)
 	if err != nil {
 		return 0, c.HandleError(err)
 	}
@@ -90,7 +154,7 @@ func (c *CRUD[T]) List(ctx context.Context, pagination PaginationParams, scanFn
 
 	query += fmt.Sprintf(" LIMIT %d OFFSET %d", pagination.Limit(), pagination.Offset())
 	
-	rows, err := c.db.Query(ctx, query, args...)
+	rows, err := c.Reader(ctx).Query(ctx, query, args...)
 	if err != nil {
 		return PaginatedResult[T]{}, c.HandleError(err)
 	}
@@ -115,13 +179,113 @@ func (c *CRUD[T]) List(ctx context.Context, pagination PaginationParams, scanFn
 	return NewPaginatedResult(items, total, pagination), nil
 }
 
+// ListKeyset retrieves a page ordered by (created_at, id) descending, seeking
+// from the position encoded in the cursor instead of using OFFSET
+func (c *CRUD[T]) ListKeyset(ctx context.Context, params CursorParams, scanFn ScanFunc[T], keyFn KeyFunc[T], whereClause string, args ...any) (CursorResult[T], error) {
+	var cursor *Cursor
+	if params.Cursor != "" {
+		decoded, err := DecodeCursor(params.Cursor)
+		if err != nil {
+			return CursorResult[T]{}, err
+		}
+		cursor = &decoded
+	}
+
+	result := CursorResult[T]{PageSize: params.Limit}
+
+	if params.IncludeTotal {
+		total, err := c.Count(ctx, whereClause, args...)
+		if err != nil {
+			return CursorResult[T]{}, err
+		}
+		result.Total = &total
+	}
+
+	conditions := make([]string, 0, 2)
+	if whereClause != "" {
+		conditions = append(conditions, "("+whereClause+")")
+	}
+
+	order := "created_at DESC, id DESC"
+	backward := cursor != nil && cursor.Backward
+
+	if cursor != nil {
+		op := "<"
+		if backward {
+			op, order = ">", "created_at ASC, id ASC"
+		}
+
+		conditions = append(conditions, fmt.Sprintf("(created_at, id) %s ($%d, $%d)", op, len(args)+1, len(args)+2))
+		args = append(args, cursor.CreatedAt, cursor.ID)
+	}
+
+	query := fmt.Sprintf("SELECT * FROM %s", c.tableName)
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// one extra row tells whether there is a page beyond this one
+	query += fmt.Sprintf(" ORDER BY %s LIMIT %d", order, params.Limit+1)
+
+	rows, err := c.Reader(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return CursorResult[T]{}, c.HandleError(err)
+	}
+
+	defer rows.Close()
+
+	items := make([]T, 0, params.Limit+1)
+	for rows.Next() {
+		item, err := scanFn(rows)
+
+		if err != nil {
+			return CursorResult[T]{}, c.HandleError(err)
+		}
+
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return CursorResult[T]{}, c.HandleError(err)
+	}
+
+	hasMore := len(items) > params.Limit
+	if hasMore {
+		items = items[:params.Limit]
+	}
+
+	if backward {
+		slices.Reverse(items)
+	}
+
+	result.Items = items
+
+	if len(items) == 0 {
+		return result, nil
+	}
+
+	// walking forward there is a previous page whenever we came from a cursor,
+	// walking backward there is a next one for the same reason
+	if (!backward && hasMore) || backward {
+		createdAt, id := keyFn(items[len(items)-1])
+		result.NextCursor = EncodeCursor(Cursor{CreatedAt: createdAt, ID: id})
+	}
+
+	if (backward && hasMore) || (!backward && cursor != nil) {
+		createdAt, id := keyFn(items[0])
+		result.PrevCursor = EncodeCursor(Cursor{CreatedAt: createdAt, ID: id, Backward: true})
+	}
+
+	return result, nil
+}
+
 // Exists checks if a record with given ID exists
 func (c *CRUD[T]) Exists(ctx context.Context, id int) (bool, error) {
 	query := fmt.Sprintf("SELECT EXISTS(SELECT 1 FROM %s WHERE id = $1)", c.tableName)
 	
 	var exists bool
 
-	err := c.db.QueryRow(ctx, query, id).Scan(&exists)
+	err := c.Reader(ctx).QueryRow(ctx, query, id).Scan(&exists)
 	if err != nil {
 		return false, c.HandleError(err)
 	}
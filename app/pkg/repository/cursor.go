@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"api/internal/domain"
+)
+
+var ErrInvalidCursor = domain.ErrInvalidInput.Explain("invalid cursor")
+
+var (
+	cursorSecret   []byte
+	cursorSecretMu sync.RWMutex
+)
+
+func init() {
+	// cursors signed with a random secret only survive until restart,
+	// SetCursorSecret makes them stable across instances
+	cursorSecret = make([]byte, 32)
+	rand.Read(cursorSecret)
+}
+
+// SetCursorSecret sets the HMAC key used to sign pagination cursors
+func SetCursorSecret(secret string) {
+	if secret == "" {
+		return
+	}
+
+	cursorSecretMu.Lock()
+	defer cursorSecretMu.Unlock()
+
+	cursorSecret = []byte(secret)
+}
+
+// Cursor is a position in a listing ordered by (created_at, id) descending
+type Cursor struct {
+	CreatedAt time.Time
+	ID        int
+	Backward  bool // walk towards newer rows
+}
+
+type cursorPayload struct {
+	T int64 `json:"t"`
+	I int   `json:"i"`
+	B bool  `json:"b,omitempty"`
+}
+
+// EncodeCursor serializes and signs a cursor into an opaque string
+func EncodeCursor(c Cursor) string {
+	data, _ := json.Marshal(cursorPayload{T: c.CreatedAt.UnixMicro(), I: c.ID, B: c.Backward})
+
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(sign(payload))
+}
+
+// DecodeCursor verifies and parses a cursor produced by EncodeCursor
+func DecodeCursor(s string) (Cursor, error) {
+	payload, signature, ok := strings.Cut(s, ".")
+	if !ok {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, sign(payload)) {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	var p cursorPayload
+	if err := json.Unmarshal(data, &p); err != nil || p.I < 1 {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return Cursor{CreatedAt: time.UnixMicro(p.T).UTC(), ID: p.I, Backward: p.B}, nil
+}
+
+func sign(payload string) []byte {
+	cursorSecretMu.RLock()
+	defer cursorSecretMu.RUnlock()
+
+	h := hmac.New(sha256.New, cursorSecret)
+	h.Write([]byte(payload))
+
+	return h.Sum(nil)[:16]
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	SetCursorSecret("test-secret")
+
+	want := Cursor{
+		CreatedAt: time.Date(2026, 2, 1, 10, 30, 0, 123456000, time.UTC),
+		ID:        42,
+		Backward:  true,
+	}
+
+	got, err := DecodeCursor(EncodeCursor(want))
+	if err != nil {
+		t.Fatalf("DecodeCursor() error = %v", err)
+	}
+
+	if !got.CreatedAt.Equal(want.CreatedAt) || got.ID != want.ID || got.Backward != want.Backward {
+		t.Errorf("DecodeCursor() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDecodeCursorRejectsTampering(t *testing.T) {
+	SetCursorSecret("test-secret")
+
+	valid := EncodeCursor(Cursor{CreatedAt: time.Now(), ID: 7})
+	payload, signature, _ := strings.Cut(valid, ".")
+
+	forged := EncodeCursor(Cursor{CreatedAt: time.Now(), ID: 8})
+	forgedPayload, _, _ := strings.Cut(forged, ".")
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"no signature", payload},
+		{"swapped payload", forgedPayload + "." + signature},
+		{"garbage", "not-a-cursor"},
+		{"empty parts", "."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeCursor(tt.cursor); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("DecodeCursor() error = %v, want %v", err, ErrInvalidCursor)
+			}
+		})
+	}
+
+	SetCursorSecret("rotated-secret")
+	if _, err := DecodeCursor(valid); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("cursor signed with old secret accepted, err = %v", err)
+	}
+}
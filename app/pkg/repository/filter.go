@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"api/internal/domain"
+)
+
+// Operator is the comparison a filter rule applies to its column
+type Operator string
+
+const (
+	OpEq     Operator = "="
+	OpGt     Operator = ">"
+	OpGte    Operator = ">="
+	OpLt     Operator = "<"
+	OpLte    Operator = "<="
+	OpPrefix Operator = "prefix"
+	OpLike   Operator = "like"
+	// OpThroughDate bounds a timestamp column by a YYYY-MM-DD date,
+	// including the whole day
+	OpThroughDate Operator = "through_date"
+)
+
+// FilterRule maps a query parameter onto a column and operator
+type FilterRule struct {
+	Column string
+	Op     Operator
+}
+
+// FilterSet whitelists the filters and sort keys an entity accepts.
+// Column names come from code only; user input is always passed as args.
+type FilterSet struct {
+	Rules       map[string]FilterRule // query parameter -> rule
+	Sortable    map[string]string     // sort key -> column
+	DefaultSort string                // ORDER BY used when no sort is requested
+}
+
+// ListOptions holds the filter values and sort expression of a list request
+type ListOptions struct {
+	Filters map[string]any
+	Sort    string // comma separated sort keys, "-" prefix for descending
+}
+
+// Compile builds a parameterized WHERE clause and ORDER BY from options.
+// Placeholders are numbered after the first argOffset args.
+func (s FilterSet) Compile(opts ListOptions, argOffset int) (where, orderBy string, args []any, err error) {
+	keys := make([]string, 0, len(opts.Filters))
+	for key := range opts.Filters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	conditions := make([]string, 0, len(keys))
+
+	for _, key := range keys {
+		rule, ok := s.Rules[key]
+		if !ok {
+			return "", "", nil, domain.ErrInvalidInput.Explain("unknown filter %q", key)
+		}
+
+		value := opts.Filters[key]
+		placeholder := fmt.Sprintf("$%d", argOffset+len(args)+1)
+
+		switch rule.Op {
+		case OpEq, OpGt, OpGte, OpLt, OpLte:
+			conditions = append(conditions, fmt.Sprintf("%s %s %s", rule.Column, rule.Op, placeholder))
+		case OpThroughDate:
+			conditions = append(conditions, fmt.Sprintf("%s < %s::date + 1", rule.Column, placeholder))
+		case OpPrefix:
+			conditions = append(conditions, fmt.Sprintf("%s ILIKE %s", rule.Column, placeholder))
+			value = escapeLike(fmt.Sprint(value)) + "%"
+		case OpLike:
+			conditions = append(conditions, fmt.Sprintf("%s ILIKE %s", rule.Column, placeholder))
+			value = "%" + escapeLike(fmt.Sprint(value)) + "%"
+		default:
+			return "", "", nil, fmt.Errorf("unsupported filter operator %q", rule.Op)
+		}
+
+		args = append(args, value)
+	}
+
+	orderBy, err = s.orderBy(opts.Sort)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	return strings.Join(conditions, " AND "), orderBy, args, nil
+}
+
+func (s FilterSet) orderBy(expr string) (string, error) {
+	if expr == "" {
+		return s.DefaultSort, nil
+	}
+
+	keys := strings.Split(expr, ",")
+	parts := make([]string, 0, len(keys)+1)
+	hasID := false
+
+	for _, key := range keys {
+		key = strings.TrimSpace(key)
+
+		direction := "ASC"
+		if strings.HasPrefix(key, "-") {
+			direction = "DESC"
+			key = key[1:]
+		}
+
+		column, ok := s.Sortable[key]
+		if !ok {
+			return "", domain.ErrInvalidInput.Explain("unknown sort field %q", key)
+		}
+
+		if column == "id" {
+			hasID = true
+		}
+
+		parts = append(parts, column+" "+direction)
+	}
+
+	// tie-breaker, so pages are stable when sort values repeat
+	if !hasID {
+		parts = append(parts, "id DESC")
+	}
+
+	return strings.Join(parts, ", "), nil
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"api/internal/domain"
+)
+
+func TestFilterSetCompile(t *testing.T) {
+	set := FilterSet{
+		Rules: map[string]FilterRule{
+			"status":      {Column: "status", Op: OpEq},
+			"term_from":   {Column: "term_months", Op: OpGte},
+			"created_to":  {Column: "created_at", Op: OpLt},
+			"created_end": {Column: "created_at", Op: OpThroughDate},
+			"name_prefix": {Column: "full_name", Op: OpPrefix},
+			"name":        {Column: "name", Op: OpLike},
+		},
+		Sortable: map[string]string{
+			"id":         "id",
+			"created_at": "created_at",
+			"term":       "term_months",
+		},
+		DefaultSort: "created_at DESC, id DESC",
+	}
+
+	tests := []struct {
+		name      string
+		opts      ListOptions
+		offset    int
+		wantWhere string
+		wantOrder string
+		wantArgs  []any
+		wantErr   error
+	}{
+		{
+			name:      "no filters uses default sort",
+			opts:      ListOptions{},
+			wantWhere: "",
+			wantOrder: "created_at DESC, id DESC",
+			wantArgs:  nil,
+		},
+		{
+			name: "filters are ordered by key and parameterized",
+			opts: ListOptions{Filters: map[string]any{
+				"term_from":  12,
+				"status":     "APPROVED",
+				"created_to": "2026-01-01",
+			}},
+			wantWhere: "created_at < $1 AND status = $2 AND term_months >= $3",
+			wantOrder: "created_at DESC, id DESC",
+			wantArgs:  []any{"2026-01-01", "APPROVED", 12},
+		},
+		{
+			name:      "date bound includes the whole day",
+			opts:      ListOptions{Filters: map[string]any{"created_end": "2026-01-31"}},
+			wantWhere: "created_at < $1::date + 1",
+			wantOrder: "created_at DESC, id DESC",
+			wantArgs:  []any{"2026-01-31"},
+		},
+		{
+			name:      "placeholders start after offset",
+			opts:      ListOptions{Filters: map[string]any{"status": "PENDING"}},
+			offset:    2,
+			wantWhere: "status = $3",
+			wantOrder: "created_at DESC, id DESC",
+			wantArgs:  []any{"PENDING"},
+		},
+		{
+			name:      "prefix escapes like wildcards",
+			opts:      ListOptions{Filters: map[string]any{"name_prefix": "50%_off"}},
+			wantWhere: "full_name ILIKE $1",
+			wantOrder: "created_at DESC, id DESC",
+			wantArgs:  []any{`50\%\_off%`},
+		},
+		{
+			name:      "like wraps value",
+			opts:      ListOptions{Filters: map[string]any{"name": "bank"}},
+			wantWhere: "name ILIKE $1",
+			wantOrder: "created_at DESC, id DESC",
+			wantArgs:  []any{"%bank%"},
+		},
+		{
+			name:      "sort keys with direction and tie-breaker",
+			opts:      ListOptions{Sort: "-term,created_at"},
+			wantOrder: "term_months DESC, created_at ASC, id DESC",
+		},
+		{
+			name:      "sort by id has no tie-breaker",
+			opts:      ListOptions{Sort: "id"},
+			wantOrder: "id ASC",
+		},
+		{
+			name:    "unknown filter",
+			opts:    ListOptions{Filters: map[string]any{"password": "x"}},
+			wantErr: domain.ErrInvalidInput,
+		},
+		{
+			name:    "unknown sort column",
+			opts:    ListOptions{Sort: "-password"},
+			wantErr: domain.ErrInvalidInput,
+		},
+		{
+			name:    "sql in sort is rejected",
+			opts:    ListOptions{Sort: "id; DROP TABLE credits"},
+			wantErr: domain.ErrInvalidInput,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where, orderBy, args, err := set.Compile(tt.opts, tt.offset)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Compile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if where != tt.wantWhere {
+				t.Errorf("where = %q, want %q", where, tt.wantWhere)
+			}
+			if orderBy != tt.wantOrder {
+				t.Errorf("orderBy = %q, want %q", orderBy, tt.wantOrder)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
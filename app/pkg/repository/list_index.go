@@ -0,0 +1,372 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"api/pkg/database"
+)
+
+const (
+	// rebuildBatch is how many ids a rebuild sends to Redis at once
+	rebuildBatch = 1000
+	// rebuildLockTTL bounds a rebuild by an instance that died during it
+	rebuildLockTTL = 5 * time.Minute
+)
+
+type ListIndexOptions struct {
+	// Key is the sorted set, e.g. "credits:list"
+	Key string
+	// Table is where Rebuild reads ids and creation times from
+	Table string
+	// Depth is how many of the newest entities are served from the index,
+	// pages reaching further go to Postgres
+	Depth int
+}
+
+// ListIndex is a Redis sorted set of ids scored by creation time. Together
+// with the entity cache it answers the first pages of the default listing,
+// newest first, without OFFSET and COUNT queries. The index only serves
+// reads while its ready marker is set: a rebuild sets it, a failed write or
+// a listed id that no longer exists clears it, and Postgres answers until
+// the next rebuild.
+type ListIndex[T any] struct {
+	opts  ListIndexOptions
+	cache *Cache[T]
+	keyFn KeyFunc[T]
+	// missed is set when a write could not reach Redis
+	missed atomic.Bool
+}
+
+type rebuilder interface {
+	name() string
+	Rebuild(ctx context.Context, db Querier) error
+	Drifted(ctx context.Context, db Querier) (bool, error)
+}
+
+var (
+	indexesMu sync.RWMutex
+	indexes   []rebuilder
+)
+
+func NewListIndex[T any](opts ListIndexOptions, cache *Cache[T], keyFn KeyFunc[T]) *ListIndex[T] {
+	idx := &ListIndex[T]{opts: opts, cache: cache, keyFn: keyFn}
+
+	indexesMu.Lock()
+	indexes = append(indexes, idx)
+	indexesMu.Unlock()
+
+	database.OnRedisRecovered(idx.recover)
+
+	return idx
+}
+
+func (l *ListIndex[T]) name() string {
+	return l.opts.Key
+}
+
+func (l *ListIndex[T]) readyKey() string {
+	return l.opts.Key + ":ready"
+}
+
+// member pads ids so that entries created in the same microsecond sort by
+// id, the sorted set orders equal scores by member bytes
+func member(id int) string {
+	return fmt.Sprintf("%019d", id)
+}
+
+func score(createdAt time.Time) float64 {
+	return float64(createdAt.UnixMicro())
+}
+
+// Add indexes the entity once the transaction in ctx commits
+func (l *ListIndex[T]) Add(ctx context.Context, entity T) {
+	createdAt, id := l.keyFn(entity)
+
+	AfterCommit(ctx, func(ctx context.Context) {
+		client := database.Redis()
+		if client == nil {
+			return
+		}
+		if cacheBypassed() {
+			l.missed.Store(true)
+			return
+		}
+
+		err := client.ZAdd(ctx, l.opts.Key, redis.Z{Score: score(createdAt), Member: member(id)}).Err()
+		if err != nil {
+			l.markDrifted(ctx, client, err)
+		}
+	})
+}
+
+// Remove drops the id from the index once the transaction in ctx commits
+func (l *ListIndex[T]) Remove(ctx context.Context, id int) {
+	AfterCommit(ctx, func(ctx context.Context) {
+		client := database.Redis()
+		if client == nil {
+			return
+		}
+		if cacheBypassed() {
+			l.missed.Store(true)
+			return
+		}
+
+		if err := client.ZRem(ctx, l.opts.Key, member(id)).Err(); err != nil {
+			l.markDrifted(ctx, client, err)
+		}
+	})
+}
+
+func (l *ListIndex[T]) markDrifted(ctx context.Context, client *redis.Client, cause error) {
+	slog.Warn("list index out of sync", "index", l.opts.Key, "err", cause)
+
+	if err := client.Del(ctx, l.readyKey()).Err(); err != nil {
+		l.missed.Store(true)
+	}
+}
+
+// recover runs when Redis is back and stops reads from an index that
+// missed writes meanwhile, until MaintainListIndexes rebuilds it
+func (l *ListIndex[T]) recover(ctx context.Context) {
+	if !l.missed.Swap(false) {
+		return
+	}
+
+	if client := database.Redis(); client != nil {
+		l.markDrifted(ctx, client, errors.New("writes missed while redis was down"))
+	}
+}
+
+// Page returns the page from the index, ok is false when it cannot be
+// served from there and the caller has to query Postgres. loadMany fills
+// entities missing from the cache.
+func (l *ListIndex[T]) Page(ctx context.Context, p PaginationParams, loadMany func(ctx context.Context, ids []int) ([]T, error)) (result PaginatedResult[T], ok bool) {
+	client := database.Redis()
+	if client == nil || cacheBypassed() || InTx(ctx) || p.Offset()+p.Limit() > l.opts.Depth {
+		return result, false
+	}
+
+	pipe := client.Pipeline()
+	ready := pipe.Exists(ctx, l.readyKey())
+	total := pipe.ZCard(ctx, l.opts.Key)
+	members := pipe.ZRevRange(ctx, l.opts.Key, int64(p.Offset()), int64(p.Offset()+p.Limit()-1))
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		slog.Warn("list index read failed", "index", l.opts.Key, "err", err)
+		return result, false
+	}
+
+	if ready.Val() == 0 {
+		return result, false
+	}
+
+	ids := make([]int, 0, len(members.Val()))
+	for _, m := range members.Val() {
+		id, err := strconv.Atoi(m)
+		if err != nil {
+			l.markDrifted(ctx, client, err)
+			return result, false
+		}
+		ids = append(ids, id)
+	}
+
+	found, err := l.cache.GetMany(ctx, ids, func(entity T) int {
+		_, id := l.keyFn(entity)
+		return id
+	}, loadMany)
+	if err != nil {
+		slog.Warn("list index load failed", "index", l.opts.Key, "err", err)
+		return result, false
+	}
+
+	items := make([]T, 0, len(ids))
+	for _, id := range ids {
+		entity, exists := found[id]
+		if !exists {
+			l.markDrifted(ctx, client, fmt.Errorf("id %d is indexed but does not exist", id))
+			return result, false
+		}
+		items = append(items, entity)
+	}
+
+	return NewPaginatedResult(items, total.Val(), p), true
+}
+
+// Rebuild fills the index from the table and marks it ready. Rows written
+// while it runs are indexed again afterwards, rows deleted meanwhile are
+// caught by Page.
+func (l *ListIndex[T]) Rebuild(ctx context.Context, db Querier) error {
+	client := database.Redis()
+	if client == nil {
+		return errors.New("redis is not connected")
+	}
+
+	// instances starting together rebuild once
+	lock := l.opts.Key + ":rebuild:lock"
+	acquired, err := client.SetNX(ctx, lock, 1, rebuildLockTTL).Result()
+	if err != nil || !acquired {
+		return err
+	}
+	defer client.Del(ctx, lock)
+
+	started := time.Now()
+	tmp := l.opts.Key + ":rebuild"
+
+	if err := client.Del(ctx, tmp).Err(); err != nil {
+		return err
+	}
+
+	if _, err := l.copyRows(ctx, db, client, tmp, time.Time{}); err != nil {
+		client.Del(ctx, tmp)
+		return err
+	}
+
+	// RENAME fails on a missing key, an empty table leaves no tmp set
+	if err := client.Rename(ctx, tmp, l.opts.Key).Err(); err != nil {
+		if err := client.Del(ctx, l.opts.Key).Err(); err != nil {
+			return err
+		}
+	}
+
+	// clocks of the API instances are not in sync, look back generously
+	n, err := l.copyRows(ctx, db, client, l.opts.Key, started.Add(-time.Minute))
+	if err != nil {
+		return err
+	}
+
+	if err := client.Set(ctx, l.readyKey(), started.Unix(), 0).Err(); err != nil {
+		return err
+	}
+
+	slog.Info("list index rebuilt", "index", l.opts.Key, "took", time.Since(started), "caught_up", n)
+
+	return nil
+}
+
+// copyRows adds the ids created since from to key
+func (l *ListIndex[T]) copyRows(ctx context.Context, db Querier, client *redis.Client, key string, from time.Time) (int, error) {
+	query := fmt.Sprintf("SELECT id, created_at FROM %s WHERE created_at >= $1", l.opts.Table)
+
+	rows, err := db.Query(ctx, query, from)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	batch := make([]redis.Z, 0, rebuildBatch)
+	total := 0
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		err := client.ZAdd(ctx, key, batch...).Err()
+		total += len(batch)
+		batch = batch[:0]
+
+		return err
+	}
+
+	for rows.Next() {
+		var id int
+		var createdAt time.Time
+		if err := rows.Scan(&id, &createdAt); err != nil {
+			return total, err
+		}
+
+		batch = append(batch, redis.Z{Score: score(createdAt), Member: member(id)})
+		if len(batch) == rebuildBatch {
+			if err := flush(); err != nil {
+				return total, err
+			}
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return total, err
+	}
+
+	return total, flush()
+}
+
+// Drifted reports whether the index lost its ready marker or holds another
+// number of ids than the table
+func (l *ListIndex[T]) Drifted(ctx context.Context, db Querier) (bool, error) {
+	client := database.Redis()
+	if client == nil {
+		return false, errors.New("redis is not connected")
+	}
+
+	pipe := client.Pipeline()
+	ready := pipe.Exists(ctx, l.readyKey())
+	indexed := pipe.ZCard(ctx, l.opts.Key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, err
+	}
+
+	if ready.Val() == 0 {
+		return true, nil
+	}
+
+	var count int64
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s", l.opts.Table)
+	if err := db.QueryRow(ctx, query).Scan(&count); err != nil {
+		return false, err
+	}
+
+	return count != indexed.Val(), nil
+}
+
+// MaintainListIndexes rebuilds every list index on start and whenever one
+// drifted from its table, checking every interval until ctx is done
+func MaintainListIndexes(ctx context.Context, db Querier, interval time.Duration) {
+	indexesMu.RLock()
+	all := append([]rebuilder(nil), indexes...)
+	indexesMu.RUnlock()
+
+	for _, idx := range all {
+		if err := idx.Rebuild(ctx, db); err != nil {
+			slog.Error("list index rebuild failed", "index", idx.name(), "err", err)
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if cacheBypassed() {
+			continue
+		}
+
+		for _, idx := range all {
+			drifted, err := idx.Drifted(ctx, db)
+			if err != nil {
+				slog.Warn("list index check failed", "index", idx.name(), "err", err)
+				continue
+			}
+			if !drifted {
+				continue
+			}
+
+			if err := idx.Rebuild(ctx, db); err != nil {
+				slog.Error("list index rebuild failed", "index", idx.name(), "err", err)
+			}
+		}
+	}
+}
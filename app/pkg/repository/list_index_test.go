@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestListIndexOrder(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 123456000, time.UTC)
+
+	// entries as the sorted set orders them: by score, then member bytes
+	type entry struct {
+		score  float64
+		member string
+	}
+	entries := []entry{
+		{score(now), member(10)},
+		{score(now), member(9)},
+		{score(now.Add(time.Microsecond)), member(2)},
+		{score(now.Add(-time.Second)), member(100)},
+	}
+
+	slices.SortFunc(entries, func(a, b entry) int {
+		if a.score != b.score {
+			if a.score > b.score {
+				return -1
+			}
+			return 1
+		}
+		if a.member > b.member {
+			return -1
+		}
+		return 1
+	})
+
+	// the SQL order is created_at DESC, id DESC
+	want := []string{member(2), member(10), member(9), member(100)}
+	for i, e := range entries {
+		if e.member != want[i] {
+			t.Errorf("position %d: member %s, want %s", i, e.member, want[i])
+		}
+	}
+
+	if score(now) == score(now.Add(time.Microsecond)) {
+		t.Error("scores lose microseconds")
+	}
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"fmt"
 	"math"
+	"time"
 )
 
 // PaginationParams holds pagination parameters
@@ -20,6 +21,25 @@ type PaginatedResult[T any] struct {
 	TotalPages int   `json:"total_pages"`
 }
 
+// CursorParams holds keyset pagination parameters
+type CursorParams struct {
+	Cursor       string // opaque cursor from a previous page, empty for the first page
+	Limit        int
+	IncludeTotal bool // run COUNT(*) as well, off by default as it is slow on big tables
+}
+
+// CursorResult holds a keyset paginated page
+type CursorResult[T any] struct {
+	Items      []T    `json:"items"`
+	PageSize   int    `json:"page_size"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	Total      *int64 `json:"total,omitempty"`
+}
+
+// KeyFunc returns the keyset position of an item
+type KeyFunc[T any] func(item T) (createdAt time.Time, id int)
+
 // DefaultPagination returns default pagination params
 func DefaultPagination() PaginationParams {
 	return PaginationParams{
@@ -60,6 +80,21 @@ func (p PaginationParams) ToSQL() string {
 	return fmt.Sprintf("LIMIT %d OFFSET %d", p.Limit(), p.Offset())
 }
 
+// NewCursorParams creates validated keyset pagination params
+func NewCursorParams(cursor string, limit int, includeTotal bool) CursorParams {
+	if limit < 1 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	return CursorParams{
+		Cursor:       cursor,
+		Limit:        limit,
+		IncludeTotal: includeTotal,
+	}
+}
+
 // NewPaginatedResult creates a paginated result
 func NewPaginatedResult[T any](items []T, total int64, params PaginationParams) PaginatedResult[T] {
 	totalPages := int(math.Ceil(float64(total) / float64(params.PageSize)))
@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const defaultTxRetries = 3
+
+// Beginner starts transactions, implemented by *pgxpool.Pool and
+// database.Cluster
+type Beginner interface {
+	BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error)
+}
+
+type TxOptions struct {
+	IsoLevel pgx.TxIsoLevel
+	// Retries is how often fn is run again after a serialization failure or
+	// deadlock, the whole unit of work is repeated from scratch
+	Retries int
+}
+
+type TxOption func(*TxOptions)
+
+// WithIsolation sets the isolation level, read committed by default
+func WithIsolation(level pgx.TxIsoLevel) TxOption {
+	return func(o *TxOptions) {
+		o.IsoLevel = level
+	}
+}
+
+func WithRetries(retries int) TxOption {
+	return func(o *TxOptions) {
+		o.Retries = retries
+	}
+}
+
+type txKey struct{}
+
+// unitOfWork is the transaction carried by the context, with the cache
+// writes that wait for its commit
+type unitOfWork struct {
+	tx          pgx.Tx
+	afterCommit []func(ctx context.Context)
+}
+
+// WithTx runs fn in a transaction placed in ctx: repositories used with the
+// ctx passed to fn run their queries in it, whichever connection they were
+// built with. The transaction commits when fn returns nil and rolls back
+// otherwise. Cache writes registered with AfterCommit run after the commit.
+// Called inside another WithTx, fn joins the outer transaction.
+func WithTx(ctx context.Context, db Beginner, fn func(ctx context.Context) error, opts ...TxOption) error {
+	if InTx(ctx) {
+		return fn(ctx)
+	}
+
+	options := TxOptions{Retries: defaultTxRetries}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := runTx(ctx, db, options.IsoLevel, fn)
+		if err == nil || !retryable(err) || attempt >= options.Retries {
+			return err
+		}
+
+		slog.Debug("retrying transaction", "attempt", attempt+1, "err", err)
+
+		// jitter keeps the conflicting transactions from colliding again
+		delay := time.Duration(attempt+1)*10*time.Millisecond + rand.N(10*time.Millisecond)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
+
+func runTx(ctx context.Context, db Beginner, level pgx.TxIsoLevel, fn func(ctx context.Context) error) error {
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{IsoLevel: level})
+	if err != nil {
+		return err
+	}
+
+	uow := &unitOfWork{tx: tx}
+
+	if err := fn(context.WithValue(ctx, txKey{}, uow)); err != nil {
+		tx.Rollback(ctx)
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	for _, hook := range uow.afterCommit {
+		hook(ctx)
+	}
+
+	return nil
+}
+
+// InTx reports whether ctx carries a transaction started by WithTx
+func InTx(ctx context.Context) bool {
+	return txFromContext(ctx) != nil
+}
+
+// AfterCommit runs fn once the transaction in ctx commits, and never when it
+// rolls back. Outside a transaction fn runs right away.
+func AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	if uow := txFromContext(ctx); uow != nil {
+		uow.afterCommit = append(uow.afterCommit, fn)
+		return
+	}
+
+	fn(ctx)
+}
+
+func txFromContext(ctx context.Context) *unitOfWork {
+	uow, _ := ctx.Value(txKey{}).(*unitOfWork)
+	return uow
+}
+
+func retryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+
+	switch pgErr.Code {
+	case "40001", // serialization_failure
+		"40P01": // deadlock_detected
+		return true
+	}
+
+	return false
+}
+
+// txQuerier sends each query to the transaction in its ctx, if any, and to
+// the repository connection otherwise
+type txQuerier struct {
+	db Querier
+}
+
+func (q txQuerier) conn(ctx context.Context) Querier {
+	if uow := txFromContext(ctx); uow != nil {
+		return uow.tx
+	}
+
+	return q.db
+}
+
+func (q txQuerier) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return q.conn(ctx).Exec(ctx, sql, args...)
+}
+
+func (q txQuerier) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return q.conn(ctx).Query(ctx, sql, args...)
+}
+
+func (q txQuerier) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return q.conn(ctx).QueryRow(ctx, sql, args...)
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// fakeTx records what happened to it, only the methods WithTx and the
+// repositories use are implemented
+type fakeTx struct {
+	pgx.Tx
+	commitErr  error
+	committed  bool
+	rolledBack bool
+	execs      int
+}
+
+func (tx *fakeTx) Commit(ctx context.Context) error {
+	tx.committed = tx.commitErr == nil
+	return tx.commitErr
+}
+
+func (tx *fakeTx) Rollback(ctx context.Context) error {
+	tx.rolledBack = true
+	return nil
+}
+
+func (tx *fakeTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	tx.execs++
+	return pgconn.CommandTag{}, nil
+}
+
+type fakeDB struct {
+	Querier
+	txs   []*fakeTx
+	opts  []pgx.TxOptions
+	begin func() *fakeTx
+}
+
+func (db *fakeDB) BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error) {
+	tx := &fakeTx{}
+	if db.begin != nil {
+		tx = db.begin()
+	}
+
+	db.txs = append(db.txs, tx)
+	db.opts = append(db.opts, opts)
+
+	return tx, nil
+}
+
+func (db *fakeDB) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, errors.New("query ran outside the transaction")
+}
+
+var errSerialization = &pgconn.PgError{Code: "40001"}
+
+func TestWithTx(t *testing.T) {
+	boom := errors.New("boom")
+
+	tests := []struct {
+		name      string
+		fnErrs    []error
+		opts      []TxOption
+		wantErr   error
+		wantTxs   int
+		committed bool
+		hooksRan  bool
+	}{
+		{"commits", []error{nil}, nil, nil, 1, true, true},
+		{"rolls back", []error{boom}, nil, boom, 1, false, false},
+		{"retries serialization failure", []error{errSerialization, nil}, nil, nil, 2, true, true},
+		{"gives up after retries", []error{errSerialization, errSerialization}, []TxOption{WithRetries(1)}, errSerialization, 2, false, false},
+		{"does not retry other errors", []error{boom, nil}, nil, boom, 1, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDB{}
+			repo := NewBaseRepository(db)
+
+			calls, hooks := 0, 0
+			err := WithTx(context.Background(), db, func(ctx context.Context) error {
+				if _, err := repo.DB().Exec(ctx, "UPDATE"); err != nil {
+					return err
+				}
+				repo.AfterCommit(ctx, func(ctx context.Context) { hooks++ })
+
+				err := tt.fnErrs[calls]
+				calls++
+				return err
+			}, tt.opts...)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("WithTx() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(db.txs) != tt.wantTxs {
+				t.Fatalf("began %d transactions, want %d", len(db.txs), tt.wantTxs)
+			}
+
+			last := db.txs[len(db.txs)-1]
+			if last.committed != tt.committed || last.rolledBack == tt.committed {
+				t.Errorf("committed = %v, rolled back = %v", last.committed, last.rolledBack)
+			}
+			if last.execs != 1 {
+				t.Errorf("transaction ran %d queries, want 1", last.execs)
+			}
+			if (hooks == 1) != tt.hooksRan || hooks > 1 {
+				t.Errorf("after commit hooks ran %d times", hooks)
+			}
+		})
+	}
+}
+
+func TestWithTxRetriesFailedCommit(t *testing.T) {
+	db := &fakeDB{}
+	db.begin = func() *fakeTx {
+		if len(db.txs) == 0 {
+			return &fakeTx{commitErr: errSerialization}
+		}
+		return &fakeTx{}
+	}
+
+	err := WithTx(context.Background(), db, func(ctx context.Context) error { return nil },
+		WithIsolation(pgx.Serializable))
+	if err != nil {
+		t.Fatalf("WithTx() error = %v", err)
+	}
+
+	if len(db.txs) != 2 || !db.txs[1].committed {
+		t.Errorf("began %d transactions, want the second one committed", len(db.txs))
+	}
+	if db.opts[0].IsoLevel != pgx.Serializable {
+		t.Errorf("isolation = %q, want %q", db.opts[0].IsoLevel, pgx.Serializable)
+	}
+}
+
+func TestWithTxNested(t *testing.T) {
+	db := &fakeDB{}
+
+	err := WithTx(context.Background(), db, func(ctx context.Context) error {
+		return WithTx(ctx, db, func(ctx context.Context) error {
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatalf("WithTx() error = %v", err)
+	}
+
+	if len(db.txs) != 1 {
+		t.Errorf("began %d transactions, nested call must join the outer one", len(db.txs))
+	}
+}
+
+func TestAfterCommitOutsideTx(t *testing.T) {
+	ran := false
+	AfterCommit(context.Background(), func(ctx context.Context) { ran = true })
+
+	if !ran {
+		t.Error("AfterCommit outside a transaction must run right away")
+	}
+}
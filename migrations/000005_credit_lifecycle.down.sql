@@ -0,0 +1,22 @@
+ALTER TABLE credits DROP COLUMN IF EXISTS status_reason;
+
+-- Enum values cannot be dropped, recreate the type with the original set
+ALTER TABLE credits ALTER COLUMN status DROP DEFAULT;
+ALTER TYPE credit_status RENAME TO credit_status_old;
+
+CREATE TYPE credit_status AS ENUM ('PENDING', 'APPROVED', 'REJECTED');
+
+ALTER TABLE credits
+    ALTER COLUMN status TYPE credit_status USING (
+        CASE status::text
+            WHEN 'UNDER_REVIEW' THEN 'PENDING'
+            WHEN 'DISBURSED' THEN 'APPROVED'
+            WHEN 'ACTIVE' THEN 'APPROVED'
+            WHEN 'CLOSED' THEN 'APPROVED'
+            WHEN 'DEFAULTED' THEN 'APPROVED'
+            ELSE status::text
+        END
+    )::credit_status,
+    ALTER COLUMN status SET DEFAULT 'PENDING';
+
+DROP TYPE credit_status_old;
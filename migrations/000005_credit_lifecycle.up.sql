@@ -0,0 +1,8 @@
+ALTER TYPE credit_status ADD VALUE IF NOT EXISTS 'UNDER_REVIEW' AFTER 'PENDING';
+ALTER TYPE credit_status ADD VALUE IF NOT EXISTS 'DISBURSED' AFTER 'REJECTED';
+ALTER TYPE credit_status ADD VALUE IF NOT EXISTS 'ACTIVE' AFTER 'DISBURSED';
+ALTER TYPE credit_status ADD VALUE IF NOT EXISTS 'CLOSED' AFTER 'ACTIVE';
+ALTER TYPE credit_status ADD VALUE IF NOT EXISTS 'DEFAULTED' AFTER 'CLOSED';
+
+-- Reason given for the last transition (e.g. why a credit was rejected)
+ALTER TABLE credits ADD COLUMN IF NOT EXISTS status_reason TEXT;
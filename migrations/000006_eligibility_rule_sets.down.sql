@@ -0,0 +1,5 @@
+ALTER TABLE credits
+    DROP COLUMN IF EXISTS rule_set_version,
+    DROP COLUMN IF EXISTS rule_set_id;
+
+DROP TABLE IF EXISTS eligibility_rule_sets;
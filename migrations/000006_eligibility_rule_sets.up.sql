@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS eligibility_rule_sets (
+    id BIGSERIAL PRIMARY KEY,
+    bank_id BIGINT REFERENCES banks(id) ON DELETE CASCADE,
+    credit_type credit_type,
+    version INTEGER NOT NULL,
+    threshold INTEGER NOT NULL,
+    rules JSONB NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE NULLS NOT DISTINCT (bank_id, credit_type, version)
+);
+
+CREATE INDEX idx_eligibility_rule_sets_scope ON eligibility_rule_sets(bank_id, credit_type, version DESC) WHERE active;
+
+-- Global rule set equivalent to the scoring that used to be hardcoded
+INSERT INTO eligibility_rule_sets (bank_id, credit_type, version, threshold, rules)
+VALUES (NULL, NULL, 1, 50, '[
+    {"field": "age", "operator": "between", "value": [18, 70], "points": 35},
+    {"field": "age", "operator": "gt", "value": 70, "points": 15},
+    {"field": "bank_type", "operator": "eq", "value": "PRIVATE", "points": 30},
+    {"field": "bank_type", "operator": "eq", "value": "GOVERNMENT", "points": 20},
+    {"field": "country", "operator": "in", "value": ["USA", "Canada", "Chili"], "points": 35},
+    {"field": "country", "operator": "in", "value": ["Mexico", "Brazil", "Panama"], "points": 20},
+    {"field": "country", "operator": "not_in", "value": ["USA", "Canada", "Chili", "Mexico", "Brazil", "Panama"], "points": 10}
+]');
+
+-- Rule set a credit was scored with, so decisions can be reproduced
+ALTER TABLE credits
+    ADD COLUMN IF NOT EXISTS rule_set_id BIGINT REFERENCES eligibility_rule_sets(id),
+    ADD COLUMN IF NOT EXISTS rule_set_version INTEGER;
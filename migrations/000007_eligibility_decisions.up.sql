@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS eligibility_decisions (
+    id BIGSERIAL PRIMARY KEY,
+    credit_id BIGINT REFERENCES credits(id) ON DELETE CASCADE,
+    client_id BIGINT NOT NULL,
+    bank_id BIGINT NOT NULL,
+    rule_set_id BIGINT REFERENCES eligibility_rule_sets(id),
+    rule_set_version INTEGER NOT NULL,
+    score INTEGER NOT NULL,
+    threshold INTEGER NOT NULL,
+    eligible BOOLEAN NOT NULL,
+    factors JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_eligibility_decisions_credit_id ON eligibility_decisions(credit_id, created_at DESC);
+CREATE INDEX idx_eligibility_decisions_client_id ON eligibility_decisions(client_id);
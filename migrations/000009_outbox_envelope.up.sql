@@ -0,0 +1,3 @@
+ALTER TABLE outbox
+    ADD COLUMN IF NOT EXISTS schema_version INTEGER NOT NULL DEFAULT 1,
+    ADD COLUMN IF NOT EXISTS correlation_id VARCHAR(255);
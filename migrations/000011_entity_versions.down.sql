@@ -0,0 +1,3 @@
+ALTER TABLE credits DROP COLUMN IF EXISTS version;
+ALTER TABLE banks DROP COLUMN IF EXISTS version;
+ALTER TABLE clients DROP COLUMN IF EXISTS version;
@@ -0,0 +1,5 @@
+-- Optimistic locking: every update bumps the version, writes carry the
+-- version they read and fail when it moved on
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE banks ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE credits ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
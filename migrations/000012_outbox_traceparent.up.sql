@@ -0,0 +1,3 @@
+-- W3C trace context of the request that wrote the event, so the relay and
+-- consumers continue its trace
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS traceparent VARCHAR(55);
@@ -0,0 +1,12 @@
+UPDATE eligibility_decisions d
+SET factors = (
+    SELECT COALESCE(jsonb_agg(jsonb_build_object(
+               'field', e->'field',
+               'operator', r->'operator',
+               'value', r->'value',
+               'points', r->'points'
+           ) ORDER BY n, m), '[]'::jsonb)
+    FROM jsonb_array_elements(d.factors) WITH ORDINALITY AS x(e, n),
+         jsonb_array_elements(e->'matched_rules') WITH ORDINALITY AS y(r, m)
+)
+WHERE jsonb_array_length(d.factors) > 0 AND d.factors->0 ? 'matched_rules';
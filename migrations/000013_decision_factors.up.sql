@@ -0,0 +1,25 @@
+-- factors were one entry per matched rule; they become one entry per tested
+-- field with the matched rules nested. The applicant's value was not
+-- recorded, it stays null for older decisions.
+UPDATE eligibility_decisions d
+SET factors = (
+    SELECT COALESCE(jsonb_agg(jsonb_build_object(
+               'field', f.field,
+               'value', NULL,
+               'points', f.points,
+               'matched_rules', f.rules
+           ) ORDER BY f.first), '[]'::jsonb)
+    FROM (
+        SELECT e->>'field' AS field,
+               SUM((e->>'points')::int) AS points,
+               jsonb_agg(jsonb_build_object(
+                   'operator', e->'operator',
+                   'value', e->'value',
+                   'points', e->'points'
+               ) ORDER BY n) AS rules,
+               MIN(n) AS first
+        FROM jsonb_array_elements(d.factors) WITH ORDINALITY AS x(e, n)
+        GROUP BY e->>'field'
+    ) f
+)
+WHERE jsonb_array_length(d.factors) > 0 AND d.factors->0 ? 'operator';